@@ -0,0 +1,75 @@
+package regroup
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+const precOption = "prec"
+
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+	bigRatType   = reflect.TypeOf(big.Rat{})
+)
+
+// bigFloatBases are the bases supported by big.ParseFloat
+var bigFloatBases = map[int]bool{0: true, 2: true, 8: true, 10: true, 16: true}
+
+func isBigType(typ reflect.Type) bool {
+	return typ == bigIntType || typ == bigFloatType || typ == bigRatType
+}
+
+// bigParseFunc returns the parsing function of given math/big type by the format,
+// or nil if the format has options which don't apply to arbitrary-precision numbers
+func (n *numberFormat) bigParseFunc(typ reflect.Type) parseFunc {
+	if n.overflow != overflowError || n.units || n.percent {
+		return nil
+	}
+	switch typ {
+	case bigIntType:
+		return n.parseBigInt
+	case bigFloatType:
+		return n.parseBigFloat
+	}
+	return n.parseBigRat
+}
+
+func (n *numberFormat) parseBigInt(src string, _ reflect.Type) (reflect.Value, error) {
+	src = n.clean(src)
+	v, ok := new(big.Int).SetString(src, n.base)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("invalid integer %q", src)
+	}
+	return reflect.ValueOf(v).Elem(), nil
+}
+
+// parseBigFloat parses a big.Float with the precision of the format. Without a precision,
+// it's enough for all the digits of src (at least 64 bits), so decimal numbers round-trip
+func (n *numberFormat) parseBigFloat(src string, _ reflect.Type) (reflect.Value, error) {
+	src = n.clean(src)
+	prec := n.prec
+	if prec == 0 {
+		// 4 bits hold a digit of any supported base
+		prec = uint(4 * len(src))
+		if prec < 64 {
+			prec = 64
+		}
+	}
+	v, _, err := big.ParseFloat(src, n.base, prec, big.ToNearestEven)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(v).Elem(), nil
+}
+
+// parseBigRat parses a big.Rat from a fraction (such as `1/3`) or a decimal number (such as `-12.75`), which is exact
+func (n *numberFormat) parseBigRat(src string, _ reflect.Type) (reflect.Value, error) {
+	src = n.clean(src)
+	v, ok := new(big.Rat).SetString(src)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("invalid rational number %q", src)
+	}
+	return reflect.ValueOf(v).Elem(), nil
+}
@@ -0,0 +1,47 @@
+package regroup
+
+import (
+	"reflect"
+)
+
+// Optional is a field type holding the parsed group value of type T, and whether the group participated in the match.
+// A group which participated with an empty value is Valid, with the zero Value
+type Optional[T any] struct {
+	Valid bool
+	Value T
+}
+
+// Captured is a field type holding the parsed group value of type T, along with the raw group text
+// and its byte offsets in the matched string. Start and End are -1 if the group didn't participate in the match
+type Captured[T any] struct {
+	Value T
+	Raw   string
+	Start int
+	End   int
+}
+
+// capture is implemented by pointers of the generic field types which are filled with the group submatch details
+type capture interface {
+	setCapture(value reflect.Value, raw string, start, end int)
+}
+
+var captureType = reflect.TypeOf((*capture)(nil)).Elem()
+
+func (o *Optional[T]) setCapture(value reflect.Value, _ string, start, _ int) {
+	o.Valid = start >= 0
+	o.Value, _ = value.Interface().(T)
+}
+
+func (c *Captured[T]) setCapture(value reflect.Value, raw string, start, end int) {
+	c.Value, _ = value.Interface().(T)
+	c.Raw, c.Start, c.End = raw, start, end
+}
+
+// captureValueType returns the type of the Value field if given type is a capture field type
+func captureValueType(typ reflect.Type) (reflect.Type, bool) {
+	if typ.Kind() != reflect.Struct || !reflect.PtrTo(typ).Implements(captureType) {
+		return nil, false
+	}
+	field, _ := typ.FieldByName("Value")
+	return field.Type, true
+}
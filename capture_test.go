@@ -0,0 +1,88 @@
+package regroup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureTypes(t *testing.T) {
+	type Captures struct {
+		Num      Optional[int]           `regroup:"num"`
+		Str      Optional[string]        `regroup:"str"`
+		NumPtr   *Optional[uint]         `regroup:"num"`
+		Duration Captured[time.Duration] `regroup:"duration"`
+		StrRaw   Captured[string]        `regroup:"str"`
+		Date     Optional[time.Time]     `regroup:"date,2006-01-02"`
+		Required Optional[string]        `regroup:"str,required"`
+		Missing  Captured[bool]          `regroup:"missing"`
+	}
+	r := MustCompile(`(?P<duration>\S+)(?: (?P<num>\d+))?(?: (?P<str>\w*))?(?: (?P<date>[\d-]+))?(?P<missing>X)?$`)
+
+	tests := []struct {
+		name     string
+		s        string
+		wantErr  error
+		expected *Captures
+	}{
+		{
+			name: "All participated",
+			s:    "5s 12 foo 2024-03-04",
+			expected: &Captures{
+				Num:      Optional[int]{Valid: true, Value: 12},
+				Str:      Optional[string]{Valid: true, Value: "foo"},
+				NumPtr:   &Optional[uint]{Valid: true, Value: 12},
+				Duration: Captured[time.Duration]{Value: 5 * time.Second, Raw: "5s", Start: 0, End: 2},
+				StrRaw:   Captured[string]{Value: "foo", Raw: "foo", Start: 6, End: 9},
+				Date:     Optional[time.Time]{Valid: true, Value: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+				Required: Optional[string]{Valid: true, Value: "foo"},
+				Missing:  Captured[bool]{Start: -1, End: -1},
+			},
+		},
+		{
+			name:    "Required not participated",
+			s:       "5s 12",
+			wantErr: &RequiredGroupIsEmpty{},
+		},
+		{
+			name:    "Parse error",
+			s:       "5ls 12 foo",
+			wantErr: &ParseError{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &Captures{NumPtr: &Optional[uint]{}}
+			err := r.MatchToTarget(tt.s, target)
+			if tt.wantErr != nil {
+				isErrorMatch(t, tt.wantErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, target)
+		})
+	}
+}
+
+func TestCaptureTypesParticipation(t *testing.T) {
+	type Captures struct {
+		Num Optional[int]    `regroup:"num"`
+		Str Captured[string] `regroup:"str"`
+	}
+	r := MustCompileFor[Captures](`^(?P<num>\d+)?(?:,(?P<str>\w*))?$`)
+
+	got, err := r.Match("")
+	require.NoError(t, err)
+	assert.Equal(t, Captures{Str: Captured[string]{Start: -1, End: -1}}, got)
+
+	got, err = r.Match("12,")
+	require.NoError(t, err)
+	assert.Equal(t, Captures{Num: Optional[int]{Valid: true, Value: 12}, Str: Captured[string]{Start: 3, End: 3}}, got)
+
+	_, err = CompileFor[struct {
+		Ch Optional[chan int] `regroup:"num"`
+	}](`(?P<num>\d+)`)
+	isErrorMatch(t, &ValidationError{}, err)
+}
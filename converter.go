@@ -0,0 +1,107 @@
+package regroup
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// globalConverters holds the converters registered with RegisterConverter and RegisterTypeConverter
+var globalConverters = struct {
+	sync.RWMutex
+	funcs map[reflect.Type]parseFunc
+}{funcs: make(map[reflect.Type]parseFunc)}
+
+// converterParseFunc wraps a converter function returning an interface into a parseFunc of typ
+func converterParseFunc(typ reflect.Type, fn func(src string) (interface{}, error)) parseFunc {
+	return func(src string, _ reflect.Type) (reflect.Value, error) {
+		converted, err := fn(src)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ret := reflect.ValueOf(converted)
+		if !ret.IsValid() {
+			return reflect.Zero(typ), nil
+		}
+		if !ret.Type().AssignableTo(typ) {
+			return reflect.Value{}, fmt.Errorf("converter returned type \"%v\" instead of \"%v\"", ret.Type(), typ)
+		}
+		return ret, nil
+	}
+}
+
+// typedParseFunc wraps a typed converter function into a parseFunc of T
+func typedParseFunc[T any](fn func(src string) (T, error)) parseFunc {
+	return func(src string, typ reflect.Type) (reflect.Value, error) {
+		converted, err := fn(src)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(&converted).Elem(), nil
+	}
+}
+
+// typeOf returns the reflect.Type of T, which also works for interface types
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// RegisterConverter registers a converter for fields of type T, used by all the ReGroups.
+// Converters take precedence over the built-in parsing of the type.
+// Converters should be registered before matching, as the parsing of each target type is resolved once and cached
+func RegisterConverter[T any](fn func(src string) (T, error)) {
+	registerGlobalConverter(typeOf[T](), typedParseFunc(fn))
+}
+
+// RegisterTypeConverter registers a converter for fields of type typ, used by all the ReGroups.
+// The converter must return a value assignable to typ. See RegisterConverter
+func RegisterTypeConverter(typ reflect.Type, fn func(src string) (interface{}, error)) {
+	registerGlobalConverter(typ, converterParseFunc(typ, fn))
+}
+
+func registerGlobalConverter(typ reflect.Type, parse parseFunc) {
+	globalConverters.Lock()
+	defer globalConverters.Unlock()
+	globalConverters.funcs[typ] = parse
+}
+
+func getGlobalConverter(typ reflect.Type) parseFunc {
+	globalConverters.RLock()
+	defer globalConverters.RUnlock()
+	return globalConverters.funcs[typ]
+}
+
+// WithConverter is a compile option registering a converter for fields of type T, used only by the compiled ReGroup.
+// It takes precedence over converters registered with RegisterConverter and over the built-in parsing of the type
+func WithConverter[T any](fn func(src string) (T, error)) Option {
+	return func(r *ReGroup) {
+		r.setConverter(typeOf[T](), typedParseFunc(fn))
+	}
+}
+
+// WithTypeConverter is a compile option registering a converter for fields of type typ, used only by the compiled ReGroup.
+// The converter must return a value assignable to typ. See WithConverter
+func WithTypeConverter(typ reflect.Type, fn func(src string) (interface{}, error)) Option {
+	return func(r *ReGroup) {
+		r.setConverter(typ, converterParseFunc(typ, fn))
+	}
+}
+
+func (r *ReGroup) setConverter(typ reflect.Type, parse parseFunc) {
+	if r.converters == nil {
+		r.converters = make(map[reflect.Type]parseFunc)
+	}
+	r.converters[typ] = parse
+}
+
+// getParsingFunc returns the parsing function for given type.
+// The ReGroup converters are consulted first, then the global converters, and then the built-in parsing functions
+func (r *ReGroup) getParsingFunc(typ reflect.Type) parseFunc {
+	if parsingFunc, ok := r.converters[typ]; ok {
+		return parsingFunc
+	}
+	if parsingFunc := getGlobalConverter(typ); parsingFunc != nil {
+		return parsingFunc
+	}
+	return getParsingFunc(typ)
+}
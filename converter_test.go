@@ -0,0 +1,118 @@
+package regroup
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderID struct {
+	Prefix string
+	Num    int
+}
+
+type money int64
+
+type level int
+
+func parseOrderID(src string) (orderID, error) {
+	split := strings.SplitN(src, "-", 2)
+	if len(split) != 2 {
+		return orderID{}, fmt.Errorf("invalid order id %q", src)
+	}
+	num, err := strconv.Atoi(split[1])
+	if err != nil {
+		return orderID{}, err
+	}
+	return orderID{Prefix: split[0], Num: num}, nil
+}
+
+func parseMoney(src string) (money, error) {
+	f, err := strconv.ParseFloat(strings.TrimPrefix(src, "$"), 64)
+	if err != nil {
+		return 0, err
+	}
+	return money(f * 100), nil
+}
+
+// registerConverters registers the global converters of the test, and unregisters them when the test ends
+func registerConverters(t *testing.T) {
+	RegisterConverter(parseOrderID)
+	RegisterTypeConverter(reflect.TypeOf(level(0)), func(src string) (interface{}, error) {
+		switch src {
+		case "INFO":
+			return level(1), nil
+		case "ERROR":
+			return level(2), nil
+		case "WRONG":
+			return "wrong type", nil
+		}
+		return nil, fmt.Errorf("unknown level %q", src)
+	})
+	t.Cleanup(func() {
+		globalConverters.Lock()
+		defer globalConverters.Unlock()
+		delete(globalConverters.funcs, reflect.TypeOf(orderID{}))
+		delete(globalConverters.funcs, reflect.TypeOf(level(0)))
+	})
+}
+
+func TestConverters(t *testing.T) {
+	registerConverters(t)
+
+	type Order struct {
+		ID       orderID  `regroup:"id"`
+		IDPtr    *orderID `regroup:"id"`
+		Amount   money    `regroup:"amount"`
+		Level    level    `regroup:"level"`
+		Quantity int      `regroup:"quantity"`
+	}
+	expr := `(?P<level>\w+) (?P<id>\S+) (?P<amount>\S+) x(?P<quantity>\S+)`
+
+	t.Run("Global converters", func(t *testing.T) {
+		r := MustCompile(expr)
+		got := &Order{IDPtr: &orderID{}}
+		require.NoError(t, r.MatchToTarget("INFO ord-12 12 x3", got))
+		assert.Equal(t, &Order{ID: orderID{Prefix: "ord", Num: 12}, IDPtr: &orderID{Prefix: "ord", Num: 12}, Amount: 12, Level: 1, Quantity: 3}, got)
+
+		isErrorMatch(t, &ParseError{}, r.MatchToTarget("INFO ord12 12 x3", got))
+		isErrorMatch(t, &ParseError{}, r.MatchToTarget("DEBUG ord-12 12 x3", got))
+		isErrorMatch(t, &ParseError{}, r.MatchToTarget("WRONG ord-12 12 x3", got))
+	})
+
+	t.Run("ReGroup converters", func(t *testing.T) {
+		r := MustCompile(expr,
+			WithConverter(parseMoney),
+			WithConverter(func(src string) (orderID, error) {
+				return orderID{Prefix: src}, nil
+			}),
+			WithTypeConverter(reflect.TypeOf(0), func(src string) (interface{}, error) {
+				return len(src), nil
+			}),
+		)
+		got := &Order{IDPtr: &orderID{}}
+		require.NoError(t, r.MatchToTarget("ERROR ord-12 $12.5 xfoo", got))
+		assert.Equal(t, &Order{ID: orderID{Prefix: "ord-12"}, IDPtr: &orderID{Prefix: "ord-12"}, Amount: 1250, Level: 2, Quantity: 3}, got)
+
+		// Other ReGroups aren't affected
+		require.NoError(t, MustCompile(expr).MatchToTarget("ERROR ord-12 12 x3", got))
+		assert.Equal(t, money(12), got.Amount)
+	})
+
+	t.Run("Pointer converter", func(t *testing.T) {
+		type PtrOrder struct {
+			ID *orderID `regroup:"id"`
+		}
+		typed := MustCompileFor[PtrOrder](expr, WithConverter(func(src string) (*orderID, error) {
+			return &orderID{Prefix: src}, nil
+		}))
+		got, err := typed.Match("INFO ord-12 12 x3")
+		require.NoError(t, err)
+		assert.Equal(t, PtrOrder{ID: &orderID{Prefix: "ord-12"}}, got)
+	})
+}
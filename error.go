@@ -3,6 +3,7 @@ package regroup
 import (
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 // CompileError returned on regex compilation error
@@ -26,6 +27,13 @@ func (n *NotStructPtrError) Error() string {
 	return "expected struct pointer"
 }
 
+// NotStructError returned when a type bound to a ReGroup is not a struct or a struct pointer
+type NotStructError struct{ typ reflect.Type }
+
+func (n *NotStructError) Error() string {
+	return fmt.Sprintf("type \"%v\" is not a struct or a struct pointer", n.typ)
+}
+
 // UnknownGroupError returned when given regex group tag isn't exists in compiled regex groups
 type UnknownGroupError struct{ group string }
 
@@ -59,3 +67,87 @@ type RequiredGroupIsEmpty struct {
 func (r *RequiredGroupIsEmpty) Error() string {
 	return fmt.Sprintf("required regroup \"%s\" is empty for field \"%s\"", r.groupName, r.fieldName)
 }
+
+// UnknownOptionError returned when a struct tag contains an option that isn't supported
+type UnknownOptionError struct {
+	option    string
+	fieldName string
+}
+
+func (u *UnknownOptionError) Error() string {
+	return fmt.Sprintf("unknown option \"%s\" for field \"%s\"", u.option, u.fieldName)
+}
+
+// InvalidTagError returned when a struct tag can't be parsed
+type InvalidTagError struct {
+	tag       string
+	fieldName string
+	err       error
+}
+
+func (i *InvalidTagError) Error() string {
+	return fmt.Sprintf("invalid tag \"%s\" for field \"%s\": %v", i.tag, i.fieldName, i.err)
+}
+
+// InvalidOptionValueError returned when the value of a struct tag option is invalid
+type InvalidOptionValueError struct {
+	option    string
+	value     string
+	fieldName string
+	err       error
+}
+
+func (i *InvalidOptionValueError) Error() string {
+	if i.err == nil {
+		return fmt.Sprintf("invalid value \"%s\" of option \"%s\" for field \"%s\"", i.value, i.option, i.fieldName)
+	}
+	return fmt.Sprintf("invalid value \"%s\" of option \"%s\" for field \"%s\": %v", i.value, i.option, i.fieldName, i.err)
+}
+
+// UnknownPatternError returned when a struct tag refers to a pattern that wasn't registered with WithPattern
+type UnknownPatternError struct {
+	pattern   string
+	fieldName string
+}
+
+func (u *UnknownPatternError) Error() string {
+	return fmt.Sprintf("unknown pattern \"%s\" for field \"%s\"", u.pattern, u.fieldName)
+}
+
+// InvalidOptionError returned when a struct tag option can't be used with the field type
+type InvalidOptionError struct {
+	option    string
+	fieldName string
+	typ       reflect.Type
+}
+
+func (i *InvalidOptionError) Error() string {
+	return fmt.Sprintf("option \"%s\" can't be used for field \"%s\" of type \"%v\"", i.option, i.fieldName, i.typ)
+}
+
+// RecursiveTypeError returned when a nested struct field is of a type containing it, without a prefix ending the recursion
+type RecursiveTypeError struct {
+	typ       reflect.Type
+	fieldName string
+}
+
+func (r *RecursiveTypeError) Error() string {
+	return fmt.Sprintf("field \"%s\" of recursive type \"%v\" needs a prefix option or the \"-\" tag", r.fieldName, r.typ)
+}
+
+// ValidationError returned when a struct type doesn't fit the compiled regex.
+// It holds all the problems found in the struct
+type ValidationError struct{ errs []error }
+
+func (v *ValidationError) Error() string {
+	msgs := make([]string, len(v.errs))
+	for i, err := range v.errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("invalid target: %s", strings.Join(msgs, "; "))
+}
+
+// Errors returns all the problems found in the struct
+func (v *ValidationError) Errors() []error {
+	return v.errs
+}
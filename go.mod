@@ -2,10 +2,7 @@ module github.com/oriser/regroup
 
 go 1.18
 
-require (
-	github.com/stretchr/testify v1.6.1
-	golang.org/x/exp v0.0.0-20230425010034-47ecfdc1ba53
-)
+require github.com/stretchr/testify v1.6.1
 
 require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
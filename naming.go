@@ -0,0 +1,85 @@
+package regroup
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy maps struct fields without a group name in their tag to groups, see WithNaming
+type NamingStrategy int
+
+const (
+	// ExactNaming maps a field to the group with the same name as the field
+	ExactNaming NamingStrategy = iota + 1
+	// SnakeCaseNaming maps a field to the group with the snake_case name of the field, such as `user_id` for UserID
+	SnakeCaseNaming
+	// CaseInsensitiveNaming maps a field to the group with the same name as the field, ignoring case
+	CaseInsensitiveNaming
+	// JSONTagNaming maps a field to the group with the name in its `json` tag, or with the same name as the field if it has none
+	JSONTagNaming
+)
+
+// WithNaming is a compile option mapping exported fields without a group name in their tag to groups by given naming strategy.
+// Fields without a matching group are skipped, use the `regroup:"-"` tag to skip a field which has one
+func WithNaming(strategy NamingStrategy) Option {
+	return func(r *ReGroup) {
+		r.naming = strategy
+	}
+}
+
+// fieldGroup returns the name of the group the field is mapped to by the naming strategy, with prefix prepended to it,
+// or an empty string if there's no matching group in the expression
+func (r *ReGroup) fieldGroup(field reflect.StructField, expr *expression, prefix string) string {
+	var name string
+	switch r.naming {
+	case ExactNaming, CaseInsensitiveNaming:
+		name = field.Name
+	case SnakeCaseNaming:
+		name = snakeCase(field.Name)
+	case JSONTagNaming:
+		name = field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == skipKey {
+				return ""
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+	default:
+		return ""
+	}
+
+	name = prefix + name
+	if _, ok := expr.groupIndices[name]; ok {
+		return name
+	}
+	if r.naming == CaseInsensitiveNaming {
+		// The first group in the expression wins if several groups match
+		for _, group := range expr.matcher.SubexpNames() {
+			if group != "" && strings.EqualFold(group, name) {
+				return group
+			}
+		}
+	}
+	return ""
+}
+
+// snakeCase converts a Go field name to snake_case, keeping acronyms together (UserID -> user_id, HTTPServer -> http_server)
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, c := range runes {
+		if unicode.IsUpper(c) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(c))
+	}
+	return b.String()
+}
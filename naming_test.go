@@ -0,0 +1,83 @@
+package regroup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNaming(t *testing.T) {
+	type User struct {
+		UserID   int
+		Name     string `json:"login,omitempty"`
+		HTTPPort uint16
+		Email    string `regroup:"mail"`
+		Skipped  string `regroup:"-"`
+		NoGroup  string
+		Nested   struct {
+			Age int
+		}
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		naming   NamingStrategy
+		expected User
+	}{
+		{
+			name:     "Exact",
+			expr:     `(?P<UserID>\d+) (?P<Name>\w+) (?P<HTTPPort>\d+) (?P<mail>\S+) (?P<Skipped>\w+) (?P<Age>\d+)`,
+			naming:   ExactNaming,
+			expected: User{UserID: 1, Name: "bob", HTTPPort: 80, Email: "bob@example.com", Nested: struct{ Age int }{Age: 30}},
+		},
+		{
+			name:     "Snake case",
+			expr:     `(?P<user_id>\d+) (?P<name>\w+) (?P<http_port>\d+) (?P<mail>\S+) (?P<skipped>\w+) (?P<age>\d+)`,
+			naming:   SnakeCaseNaming,
+			expected: User{UserID: 1, Name: "bob", HTTPPort: 80, Email: "bob@example.com", Nested: struct{ Age int }{Age: 30}},
+		},
+		{
+			name:     "Case insensitive",
+			expr:     `(?P<userid>\d+) (?P<NAME>\w+) (?P<httpPort>\d+) (?P<mail>\S+) (?P<skipped>\w+) (?P<age>\d+)`,
+			naming:   CaseInsensitiveNaming,
+			expected: User{UserID: 1, Name: "bob", HTTPPort: 80, Email: "bob@example.com", Nested: struct{ Age int }{Age: 30}},
+		},
+		{
+			name:     "JSON tag",
+			expr:     `(?P<UserID>\d+) (?P<login>\w+) (?P<HTTPPort>\d+) (?P<mail>\S+) (?P<Skipped>\w+) (?P<Age>\d+)`,
+			naming:   JSONTagNaming,
+			expected: User{UserID: 1, Name: "bob", HTTPPort: 80, Email: "bob@example.com", Nested: struct{ Age int }{Age: 30}},
+		},
+		{
+			name:     "No naming",
+			expr:     `(?P<UserID>\d+) (?P<Name>\w+) (?P<HTTPPort>\d+) (?P<mail>\S+) (?P<Skipped>\w+) (?P<Age>\d+)`,
+			expected: User{Email: "bob@example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.naming != 0 {
+				opts = append(opts, WithNaming(tt.naming))
+			}
+			r := MustCompileFor[User](tt.expr, opts...)
+			got, err := r.Match("1 bob 80 bob@example.com skip 30")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Name":       "name",
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"Port2":      "port2",
+		"Port2Name":  "port2_name",
+	} {
+		assert.Equal(t, expected, snakeCase(name), name)
+	}
+}
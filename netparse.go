@@ -0,0 +1,60 @@
+package regroup
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"reflect"
+)
+
+func parseAddr(src string, _ reflect.Type) (reflect.Value, error) {
+	addr, err := netip.ParseAddr(src)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(addr), nil
+}
+
+func parsePrefix(src string, _ reflect.Type) (reflect.Value, error) {
+	prefix, err := netip.ParsePrefix(src)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(prefix), nil
+}
+
+func parseIP(src string, _ reflect.Type) (reflect.Value, error) {
+	ip := net.ParseIP(src)
+	if ip == nil {
+		return reflect.Value{}, fmt.Errorf("invalid IP address %q", src)
+	}
+	return reflect.ValueOf(ip), nil
+}
+
+// parseHardwareAddr parses a MAC address (such as `00:1a:2b:3c:4d:5e`), in any of the formats of net.ParseMAC
+func parseHardwareAddr(src string, _ reflect.Type) (reflect.Value, error) {
+	mac, err := net.ParseMAC(src)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(mac), nil
+}
+
+func parseURL(src string, _ reflect.Type) (reflect.Value, error) {
+	u, err := url.Parse(src)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(u).Elem(), nil
+}
+
+// parseMailAddress parses an RFC 5322 address, such as `Gopher <gopher@example.com>` or `gopher@example.com`
+func parseMailAddress(src string, _ reflect.Type) (reflect.Value, error) {
+	addr, err := mail.ParseAddress(src)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(addr).Elem(), nil
+}
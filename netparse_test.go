@@ -0,0 +1,60 @@
+package regroup
+
+import (
+	"net"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkTypes(t *testing.T) {
+	type AccessLog struct {
+		Addr      netip.Addr       `regroup:"ip"`
+		AddrPtr   *netip.Addr      `regroup:"ip"`
+		Prefix    netip.Prefix     `regroup:"cidr"`
+		IP        net.IP           `regroup:"ip"`
+		IPPtr     *net.IP          `regroup:"ip"`
+		Resolvers []net.IP         `regroup:"resolvers,split=|"`
+		MAC       net.HardwareAddr `regroup:"mac"`
+		URL       url.URL          `regroup:"url"`
+		URLPtr    *url.URL         `regroup:"url"`
+		From      mail.Address     `regroup:"from"`
+		FromPtr   *mail.Address    `regroup:"from"`
+	}
+	r := MustCompile(`^(?P<ip>\S+) (?P<cidr>\S+) (?P<resolvers>\S*) (?P<mac>\S+) (?P<url>\S+) (?P<from>.+)$`)
+
+	target := &AccessLog{}
+	require.NoError(t, r.MatchToTarget("10.0.0.1 10.0.0.0/8 1.1.1.1|2001:db8::1 00:1a:2b:3c:4d:5e https://example.com/a?b=c Gopher <gopher@example.com>", target))
+	addr := netip.MustParseAddr("10.0.0.1")
+	ip := net.ParseIP("10.0.0.1")
+	u := url.URL{Scheme: "https", Host: "example.com", Path: "/a", RawQuery: "b=c"}
+	from := mail.Address{Name: "Gopher", Address: "gopher@example.com"}
+	assert.Equal(t, &AccessLog{
+		Addr:      addr,
+		AddrPtr:   &addr,
+		Prefix:    netip.MustParsePrefix("10.0.0.0/8"),
+		IP:        ip,
+		IPPtr:     &ip,
+		Resolvers: []net.IP{net.ParseIP("1.1.1.1"), net.ParseIP("2001:db8::1")},
+		MAC:       net.HardwareAddr{0x00, 0x1a, 0x2b, 0x3c, 0x4d, 0x5e},
+		URL:       u,
+		URLPtr:    &u,
+		From:      from,
+		FromPtr:   &from,
+	}, target)
+
+	for _, s := range []string{
+		"10.0.0 10.0.0.0/8  00:1a:2b:3c:4d:5e http://a gopher@example.com",
+		"10.0.0.1 10.0.0.0/33  00:1a:2b:3c:4d:5e http://a gopher@example.com",
+		"10.0.0.1 10.0.0.0/8 1.1.1 00:1a:2b:3c:4d:5e http://a gopher@example.com",
+		"10.0.0.1 10.0.0.0/8  00:1a:2b http://a gopher@example.com",
+		"10.0.0.1 10.0.0.0/8  00:1a:2b:3c:4d:5e http://[::1 gopher@example.com",
+		"10.0.0.1 10.0.0.0/8  00:1a:2b:3c:4d:5e http://a gopher",
+	} {
+		isErrorMatch(t, &ParseError{}, r.MatchToTarget(s, &AccessLog{}))
+	}
+}
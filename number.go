@@ -0,0 +1,311 @@
+package regroup
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	wrapOption     = "wrap"
+	saturateOption = "saturate"
+	baseOption     = "base"
+	sepOption      = "sep"
+)
+
+// overflowMode is the handling of numbers which are out of the range of the field type
+type overflowMode int
+
+const (
+	// overflowError returns a ParseError describing the range violation
+	overflowError overflowMode = iota
+	// overflowWrap parses the number as 64 bits and converts it to the field type, wrapping integers and rounding floats to infinity
+	overflowWrap
+	// overflowSaturate clamps the number to the range of the field type
+	overflowSaturate
+)
+
+// numberOptions are the options which can be used only for numeric fields
+var numberOptions = map[string]bool{
+	wrapOption:     true,
+	saturateOption: true,
+	baseOption:     true,
+	sepOption:      true,
+	bytesOption:    true,
+	siOption:       true,
+	iecOption:      true,
+	percentOption:  true,
+	precOption:     true,
+}
+
+// basePrefixes are the prefixes which are allowed for integers in the matching base, as with base 0
+var basePrefixes = map[int][]string{
+	2:  {"0b", "0B"},
+	8:  {"0o", "0O"},
+	16: {"0x", "0X"},
+}
+
+// numberFormat is the precompiled instructions for parsing numeric values, set by the tag options
+type numberFormat struct {
+	overflow overflowMode
+	// base is the base of integers, 0 detects the base by the prefix of the number as in Go literals
+	base int
+	// separators are the digit separators removed from the number before parsing (such as thousands separators)
+	separators string
+	// units is set for numbers with unit prefixes, which are of 1024 if binary is set, or of 1000 otherwise.
+	// bytes allows the `B` unit after the prefix
+	units  bool
+	binary bool
+	bytes  bool
+	// percent is set for percentages, which are parsed into fractions
+	percent bool
+	// prec is the precision of big.Float values in bits, 0 fits the precision to the parsed number
+	prec uint
+}
+
+// defaultNumberFormat is used by numeric fields without number options
+var defaultNumberFormat = &numberFormat{base: 10}
+
+// usesNumberParsing checks if given type is parsed by the built-in parsing function of its kind or by the math/big
+// parsing functions, rather than a converter, an unmarshaler or another type specific parsing function
+func (r *ReGroup) usesNumberParsing(typ reflect.Type) bool {
+	if _, ok := r.converters[typ]; ok {
+		return false
+	}
+	if getGlobalConverter(typ) != nil {
+		return false
+	}
+	if isBigType(typ) {
+		return true
+	}
+	if _, ok := typesParsingFuncs[typ]; ok {
+		return false
+	}
+	return getUnmarshalerParsingFunc(typ) == nil
+}
+
+// applyNumberFormat resolves the number options of a field, and sets its parsing function by them.
+// The options which aren't number options are returned
+func (b *planBuilder) applyNumberFormat(plan *fieldPlan, fieldType reflect.StructField, options []tagOption) (left []tagOption, errs []error) {
+	format := &numberFormat{base: 10}
+	hasOptions, hasPrefixes := false, false
+	for _, option := range options {
+		if !numberOptions[option.key] {
+			left = append(left, option)
+			continue
+		}
+		hasOptions = true
+
+		switch option.key {
+		case wrapOption, saturateOption:
+			if format.overflow != overflowError || option.hasValue {
+				errs = append(errs, &InvalidOptionError{option: option.key, fieldName: fieldType.Name, typ: fieldType.Type})
+			}
+			format.overflow = overflowWrap
+			if option.key == saturateOption {
+				format.overflow = overflowSaturate
+			}
+		case baseOption:
+			base, err := strconv.Atoi(option.value)
+			if err != nil || base == 1 || base < 0 || base > 36 {
+				errs = append(errs, &InvalidOptionValueError{option: option.key, value: option.value, fieldName: fieldType.Name})
+				continue
+			}
+			format.base = base
+		case sepOption:
+			if option.value == "" {
+				errs = append(errs, &InvalidOptionValueError{option: option.key, value: option.value, fieldName: fieldType.Name})
+			}
+			format.separators = option.value
+		case bytesOption:
+			format.units, format.bytes = true, true
+		case siOption, iecOption:
+			if hasPrefixes {
+				// si and iec can't be used together
+				errs = append(errs, &InvalidOptionError{option: option.key, fieldName: fieldType.Name, typ: fieldType.Type})
+			}
+			hasPrefixes = true
+			format.units = true
+			format.binary = option.key == iecOption
+		case percentOption:
+			format.percent = true
+		case precOption:
+			prec, err := strconv.ParseUint(option.value, 10, 32)
+			if err != nil || prec == 0 || prec > big.MaxPrec {
+				errs = append(errs, &InvalidOptionValueError{option: option.key, value: option.value, fieldName: fieldType.Name})
+				continue
+			}
+			format.prec = uint(prec)
+		}
+	}
+	switch {
+	case format.base != 10 && !format.allowsBase(plan.valueType):
+		errs = append(errs, &InvalidOptionError{option: baseOption, fieldName: fieldType.Name, typ: fieldType.Type})
+	case format.prec != 0 && plan.valueType != bigFloatType:
+		errs = append(errs, &InvalidOptionError{option: precOption, fieldName: fieldType.Name, typ: fieldType.Type})
+	case format.percent && (format.units || !isFloatKind(plan.valueType.Kind())):
+		// Percentages are parsed into fractions, so only floats can hold them
+		errs = append(errs, &InvalidOptionError{option: percentOption, fieldName: fieldType.Name, typ: fieldType.Type})
+	}
+	if !hasOptions {
+		return left, errs
+	}
+
+	parse := format.parseFunc(plan.valueType)
+	if parse == nil || plan.parse == nil || !b.r.usesNumberParsing(plan.valueType) {
+		return left, append(errs, &InvalidOptionError{option: numberOptionKeys(options), fieldName: fieldType.Name, typ: fieldType.Type})
+	}
+	plan.parse = parse
+	return left, errs
+}
+
+// numberOptionKeys returns the keys of the number options, for reporting them together
+func numberOptionKeys(options []tagOption) string {
+	var keys []string
+	for _, option := range options {
+		if numberOptions[option.key] {
+			keys = append(keys, option.key)
+		}
+	}
+	return strings.Join(keys, ",")
+}
+
+func isFloatKind(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
+
+// allowsBase checks if the base of the format can be used for typ
+func (n *numberFormat) allowsBase(typ reflect.Type) bool {
+	switch {
+	case n.units || n.percent || typ == bigRatType:
+		return false
+	case typ == bigFloatType:
+		return bigFloatBases[n.base]
+	}
+	return !isFloatKind(typ.Kind())
+}
+
+// clean removes the digit separators from the number, and the base prefix if the base is explicit
+func (n *numberFormat) clean(src string) string {
+	if n.separators != "" {
+		src = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(n.separators, r) {
+				return -1
+			}
+			return r
+		}, src)
+	}
+
+	sign := ""
+	if len(src) > 0 && (src[0] == '-' || src[0] == '+') {
+		sign, src = src[:1], src[1:]
+	}
+	for _, prefix := range basePrefixes[n.base] {
+		if strings.HasPrefix(src, prefix) {
+			src = src[len(prefix):]
+			break
+		}
+	}
+	return sign + src
+}
+
+// parseFunc returns the parsing function of given numeric type by the format, or nil if the type isn't numeric
+func (n *numberFormat) parseFunc(typ reflect.Type) parseFunc {
+	if isBigType(typ) {
+		return n.bigParseFunc(typ)
+	}
+	if n.units || n.percent {
+		if defaultNumberFormat.parseFunc(typ) == nil {
+			return nil
+		}
+		return n.parseScaled
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return n.parseInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return n.parseUInt
+	case reflect.Float32, reflect.Float64:
+		return n.parseFloat
+	}
+	return nil
+}
+
+// bitSize returns the bit size to parse typ with, numbers wrapped on overflow are parsed as 64 bits and converted
+func (n *numberFormat) bitSize(typ reflect.Type) int {
+	if n.overflow == overflowWrap {
+		return 64
+	}
+	return typ.Bits()
+}
+
+// rangeError returns the error of a number which is out of the range of typ.
+// Range errors aren't returned when saturating, as strconv returns the value clamped to the bit size along with them
+func (n *numberFormat) rangeError(err error, src string, typ reflect.Type) error {
+	if !errors.Is(err, strconv.ErrRange) {
+		return err
+	}
+	if n.overflow == overflowSaturate {
+		return nil
+	}
+
+	bits := typ.Bits()
+	switch {
+	case n.overflow == overflowWrap:
+		return fmt.Errorf("value %q is out of range of 64 bits numbers", src)
+	case typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Int64:
+		return fmt.Errorf("value %q is out of range of %v [%d, %d]", src, typ, int64(-1)<<(bits-1), int64(1)<<(bits-1)-1)
+	case typ.Kind() >= reflect.Uint && typ.Kind() <= reflect.Uintptr:
+		return fmt.Errorf("value %q is out of range of %v [0, %d]", src, typ, uint64(math.MaxUint64)>>(64-bits))
+	}
+	return fmt.Errorf("value %q is out of range of %v", src, typ)
+}
+
+func (n *numberFormat) parseInt(src string, typ reflect.Type) (reflect.Value, error) {
+	src = n.clean(src)
+	v, err := strconv.ParseInt(src, n.base, n.bitSize(typ))
+	if err != nil {
+		if err = n.rangeError(err, src, typ); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	return reflect.ValueOf(v).Convert(typ), nil
+}
+
+func (n *numberFormat) parseUInt(src string, typ reflect.Type) (reflect.Value, error) {
+	src = n.clean(src)
+	v, err := strconv.ParseUint(src, n.base, n.bitSize(typ))
+	if err != nil && n.overflow == overflowSaturate && strings.HasPrefix(src, "-") {
+		// Negative numbers are saturated to zero
+		if _, intErr := strconv.ParseInt(src, n.base, 64); intErr == nil || errors.Is(intErr, strconv.ErrRange) {
+			return reflect.Zero(typ), nil
+		}
+	}
+	if err != nil {
+		if err = n.rangeError(err, src, typ); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	return reflect.ValueOf(v).Convert(typ), nil
+}
+
+func (n *numberFormat) parseFloat(src string, typ reflect.Type) (reflect.Value, error) {
+	src = n.clean(src)
+	v, err := strconv.ParseFloat(src, n.bitSize(typ))
+	if err != nil {
+		if err = n.rangeError(err, src, typ); err != nil {
+			return reflect.Value{}, err
+		}
+		// strconv returns an infinity on overflow
+		max := math.MaxFloat64
+		if typ.Kind() == reflect.Float32 {
+			max = math.MaxFloat32
+		}
+		v = math.Copysign(max, v)
+	}
+	return reflect.ValueOf(v).Convert(typ), nil
+}
@@ -0,0 +1,283 @@
+package regroup
+
+import (
+	"math"
+	"math/big"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNumberOverflow(t *testing.T) {
+	type Numbers struct {
+		Int8    int8    `regroup:"num"`
+		Uint16  uint16  `regroup:"num"`
+		Float32 float32 `regroup:"float"`
+	}
+	r := MustCompile(`^(?P<num>-?\d+)?/(?P<float>\S+)?$`)
+
+	target := &Numbers{}
+	require.NoError(t, r.MatchToTarget("127/3.5", target))
+	assert.Equal(t, &Numbers{Int8: 127, Uint16: 127, Float32: 3.5}, target)
+
+	for _, s := range []string{"300/", "-129/", "99999999999999999999/", "/1e40", "/-1e40"} {
+		err := r.MatchToTarget(s, &Numbers{})
+		isErrorMatch(t, &ParseError{}, err)
+		assert.Contains(t, err.Error(), "out of range", s)
+	}
+	err := r.MatchToTarget("300/", &Numbers{})
+	assert.Contains(t, err.Error(), "int8 [-128, 127]")
+}
+
+func TestNumberOverflowOptions(t *testing.T) {
+	type Wrapped struct {
+		Int8    int8    `regroup:"num,wrap"`
+		Uint8   uint8   `regroup:"num,wrap"`
+		Float32 float32 `regroup:"float,wrap"`
+	}
+	type Saturated struct {
+		Int8    int8            `regroup:"num,saturate"`
+		Uint8   uint8           `regroup:"num,saturate"`
+		Uint64s []uint64        `regroup:"num,saturate"`
+		Float32 float32         `regroup:"float,saturate"`
+		Map     map[string]int8 `regroup:"n*,saturate"`
+	}
+	r := MustCompile(`^(?P<num>-?\d+)?/(?P<float>\S+)?$`)
+
+	wrapped := &Wrapped{}
+	require.NoError(t, r.MatchToTarget("300/1e40", wrapped))
+	assert.Equal(t, &Wrapped{Int8: 44, Uint8: 44, Float32: float32(math.Inf(1))}, wrapped)
+
+	saturated := &Saturated{}
+	require.NoError(t, r.MatchToTarget("300/1e40", saturated))
+	assert.Equal(t, &Saturated{Int8: math.MaxInt8, Uint8: math.MaxUint8, Uint64s: []uint64{300}, Float32: math.MaxFloat32, Map: map[string]int8{"um": math.MaxInt8}}, saturated)
+
+	saturated = &Saturated{}
+	require.NoError(t, r.MatchToTarget("-99999999999999999999/-1e40", saturated))
+	assert.Equal(t, &Saturated{Int8: math.MinInt8, Float32: -math.MaxFloat32, Uint64s: []uint64{0}, Map: map[string]int8{"um": math.MinInt8}}, saturated)
+
+	isErrorMatch(t, &ParseError{}, r.MatchToTarget("99999999999999999999/", &Wrapped{}))
+
+	type Invalid struct {
+		Both   int8   `regroup:"num,wrap,saturate"`
+		String string `regroup:"num,wrap"`
+		Level  level  `regroup:"num,saturate"`
+	}
+	// Number options can't be used for types parsed by a converter
+	withConverter := MustCompile(`^(?P<num>-?\d+)?$`, WithConverter(func(src string) (level, error) {
+		return level(len(src)), nil
+	}))
+	err := withConverter.Validate(reflect.TypeOf(Invalid{}))
+	require.Error(t, err)
+	errs := err.(*ValidationError).Errors()
+	require.Len(t, errs, 3)
+	for _, err := range errs {
+		assert.IsType(t, &InvalidOptionError{}, err)
+	}
+}
+
+func TestNumberBase(t *testing.T) {
+	type Numbers struct {
+		Hex      uint32  `regroup:"hex,base=16"`
+		Addr     uintptr `regroup:"hex,base=16"`
+		Octal    int     `regroup:"octal,base=8"`
+		Binary   uint8   `regroup:"binary,base=2"`
+		Auto     []int64 `regroup:"auto,split=|,base=0"`
+		Sep      int     `regroup:"sep,sep=,"`
+		SepSpace float64 `regroup:"float,sep=' _'"`
+	}
+	r := MustCompile(`^(?P<hex>\S+) (?P<octal>\S+) (?P<binary>\S+) (?P<auto>\S+) (?P<sep>\S+) (?P<float>.+)$`)
+
+	target := &Numbers{}
+	require.NoError(t, r.MatchToTarget("0xff 0o755 101 0x10|0o10|0b10|10|1_000 1,234,567 1 234_567.5", target))
+	assert.Equal(t, &Numbers{
+		Hex:      255,
+		Addr:     255,
+		Octal:    0o755,
+		Binary:   5,
+		Auto:     []int64{16, 8, 2, 10, 1000},
+		Sep:      1234567,
+		SepSpace: 1234567.5,
+	}, target)
+
+	require.NoError(t, r.MatchToTarget("FF -755 0b101 -0x10 1 1", target))
+	assert.Equal(t, uint32(255), target.Hex)
+	assert.Equal(t, uintptr(255), target.Addr)
+	assert.Equal(t, -0o755, target.Octal)
+	assert.Equal(t, uint8(5), target.Binary)
+	assert.Equal(t, []int64{-16}, target.Auto)
+
+	isErrorMatch(t, &ParseError{}, r.MatchToTarget("fg 1 1 1 1 1", &Numbers{}))
+	isErrorMatch(t, &ParseError{}, r.MatchToTarget("1 8 1 1 1 1", &Numbers{}))
+	isErrorMatch(t, &ParseError{}, r.MatchToTarget("1 1 1111111111 1 1 1", &Numbers{}))
+
+	type Invalid struct {
+		Base   int     `regroup:"hex,base=1"`
+		NaN    int     `regroup:"hex,base=x"`
+		Float  float64 `regroup:"hex,base=16"`
+		NoSep  int     `regroup:"hex,sep="`
+		String string  `regroup:"hex,base=16"`
+	}
+	err := r.Validate(reflect.TypeOf(Invalid{}))
+	require.Error(t, err)
+	errs := err.(*ValidationError).Errors()
+	require.Len(t, errs, 5)
+	assert.IsType(t, &InvalidOptionValueError{}, errs[0])
+	assert.IsType(t, &InvalidOptionValueError{}, errs[1])
+	assert.IsType(t, &InvalidOptionError{}, errs[2])
+	assert.IsType(t, &InvalidOptionValueError{}, errs[3])
+	assert.IsType(t, &InvalidOptionError{}, errs[4])
+}
+
+func TestFileMode(t *testing.T) {
+	type Modes struct {
+		Mode    os.FileMode  `regroup:"mode"`
+		ModePtr *os.FileMode `regroup:"mode"`
+	}
+	r := MustCompile(`^(?P<mode>\S*)$`)
+
+	for s, expected := range map[string]os.FileMode{
+		"755":         0o755,
+		"0644":        0o644,
+		"4755":        os.ModeSetuid | 0o755,
+		"1777":        os.ModeSticky | 0o777,
+		"rwxr-xr-x":   0o755,
+		"-rw-r--r--":  0o644,
+		"drwxr-xr-x":  os.ModeDir | 0o755,
+		"lrwxrwxrwx":  os.ModeSymlink | 0o777,
+		"-rwsr-xr-x":  os.ModeSetuid | 0o755,
+		"-rwxr-Sr-x":  os.ModeSetgid | 0o745,
+		"drwxrwxrwt":  os.ModeDir | os.ModeSticky | 0o777,
+		"-rw-r--r--@": 0o644,
+		"crw-rw-rw-":  os.ModeDevice | os.ModeCharDevice | 0o666,
+	} {
+		target := &Modes{}
+		require.NoError(t, r.MatchToTarget(s, target), s)
+		assert.Equal(t, expected, target.Mode, s)
+		assert.Equal(t, expected, *target.ModePtr, s)
+	}
+
+	for _, s := range []string{"rwxr-xr-", "xwxr-xr-x", "zrwxr-xr-x", "77777", "rwsr-xr-x9"} {
+		isErrorMatch(t, &ParseError{}, r.MatchToTarget(s, &Modes{}))
+	}
+}
+
+func TestUnits(t *testing.T) {
+	type Sizes struct {
+		Bytes    int64     `regroup:"size,bytes"`
+		IEC      uint64    `regroup:"size,bytes,iec"`
+		Float    float64   `regroup:"size,bytes"`
+		SI       []int     `regroup:"counts,split=|,si"`
+		Percent  float64   `regroup:"percent,percent"`
+		Percents []float32 `regroup:"percents,split=|,percent"`
+	}
+	r := MustCompile(`^(?P<size>[^;]*);(?P<counts>[^;]*);(?P<percent>[^;]*);(?P<percents>[^;]*)$`)
+
+	tests := []struct {
+		s        string
+		expected *Sizes
+	}{
+		{
+			s:        "12.5MB;1.5k|2M|7;87.3%;50%|1.5",
+			expected: &Sizes{Bytes: 12_500_000, IEC: 12.5 * 1024 * 1024, Float: 12_500_000, SI: []int{1500, 2_000_000, 7}, Percent: 0.873, Percents: []float32{0.5, 0.015}},
+		},
+		{
+			s:        "3GiB;;;",
+			expected: &Sizes{Bytes: 3 << 30, IEC: 3 << 30, Float: 3 << 30},
+		},
+		{
+			s:        "450 kB/s;;;",
+			expected: &Sizes{Bytes: 450_000, IEC: 450 * 1024, Float: 450_000},
+		},
+		{
+			s:        "512;;;",
+			expected: &Sizes{Bytes: 512, IEC: 512, Float: 512},
+		},
+		{
+			s:        "1.0001kB;;;",
+			expected: &Sizes{Bytes: 1000, IEC: 1024, Float: 1000.1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			target := &Sizes{}
+			require.NoError(t, r.MatchToTarget(tt.s, target))
+			assert.Equal(t, tt.expected, target)
+		})
+	}
+
+	for _, s := range []string{"12XB;;;", "MB;;;", ";1.5kB;;", ";;87.3 percent;", "16EiB;;;"} {
+		isErrorMatch(t, &ParseError{}, r.MatchToTarget(s, &Sizes{}))
+	}
+
+	type Invalid struct {
+		Both       int    `regroup:"size,si,iec"`
+		IntPercent int    `regroup:"size,percent"`
+		Base       int    `regroup:"size,bytes,base=16"`
+		String     string `regroup:"size,bytes"`
+	}
+	err := r.Validate(reflect.TypeOf(Invalid{}))
+	require.Error(t, err)
+	errs := err.(*ValidationError).Errors()
+	require.Len(t, errs, 4)
+	for _, err := range errs {
+		assert.IsType(t, &InvalidOptionError{}, err)
+	}
+}
+
+func TestBigNumbers(t *testing.T) {
+	type Amounts struct {
+		ID       big.Int    `regroup:"id"`
+		IDPtr    *big.Int   `regroup:"id"`
+		Hex      *big.Int   `regroup:"hex,base=16"`
+		Amount   big.Rat    `regroup:"amount,sep=,"`
+		Amounts  []*big.Rat `regroup:"amounts,split=|"`
+		Float    big.Float  `regroup:"amount,sep=,"`
+		FloatPtr *big.Float `regroup:"amount,sep=,,prec=24"`
+	}
+	r := MustCompile(`^(?P<id>\d+) (?P<hex>\w+) (?P<amount>[\d,.-]+)(?: (?P<amounts>.*))?$`)
+
+	target := &Amounts{}
+	require.NoError(t, r.MatchToTarget("123456789012345678901234567890 0xdeadbeefdeadbeefdeadbeef -1,234,567,890.01 1/3|0.1", target))
+	id, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	hex, _ := new(big.Int).SetString("deadbeefdeadbeefdeadbeef", 16)
+	assert.Equal(t, 0, id.Cmp(&target.ID))
+	assert.Equal(t, 0, id.Cmp(target.IDPtr))
+	assert.Equal(t, 0, hex.Cmp(target.Hex))
+	assert.Equal(t, "-123456789001/100", target.Amount.String())
+	require.Len(t, target.Amounts, 2)
+	assert.Equal(t, "1/3", target.Amounts[0].String())
+	assert.Equal(t, "1/10", target.Amounts[1].String())
+	assert.Equal(t, "-1234567890.01", target.Float.Text('f', 2))
+	assert.Equal(t, uint(24), target.FloatPtr.Prec())
+	assert.Equal(t, "-1.234568e+09", target.FloatPtr.Text('e', 6))
+
+	target = &Amounts{}
+	require.NoError(t, r.MatchToTarget("1 ff 1", target))
+	assert.Nil(t, target.Amounts)
+
+	isErrorMatch(t, &ParseError{}, r.MatchToTarget("1 fg 1", &Amounts{}))
+	isErrorMatch(t, &ParseError{}, r.MatchToTarget("1 ff 1.2.3", &Amounts{}))
+
+	type Invalid struct {
+		IntPrec  big.Int   `regroup:"id,prec=64"`
+		RatBase  big.Rat   `regroup:"id,base=16"`
+		BadBase  big.Float `regroup:"id,base=3"`
+		BadPrec  big.Float `regroup:"id,prec=0"`
+		Wrap     big.Int   `regroup:"id,wrap"`
+		Float64P float64   `regroup:"id,prec=64"`
+	}
+	err := r.Validate(reflect.TypeOf(Invalid{}))
+	require.Error(t, err)
+	errs := err.(*ValidationError).Errors()
+	require.Len(t, errs, 6)
+	assert.IsType(t, &InvalidOptionError{}, errs[0])
+	assert.IsType(t, &InvalidOptionError{}, errs[1])
+	assert.IsType(t, &InvalidOptionError{}, errs[2])
+	assert.IsType(t, &InvalidOptionValueError{}, errs[3])
+	assert.IsType(t, &InvalidOptionError{}, errs[4])
+	assert.IsType(t, &InvalidOptionError{}, errs[5])
+}
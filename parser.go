@@ -1,72 +1,145 @@
 package regroup
 
 import (
+	"encoding"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"os"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// Unmarshaler is implemented by types that can parse a matched group by themselves.
+// It takes precedence over encoding.TextUnmarshaler and the kind-based parsing
+type Unmarshaler interface {
+	UnmarshalRegroup(group string) error
+}
+
+// setter is implemented by flag.Value-style types
+type setter interface {
+	Set(string) error
+}
+
+var (
+	unmarshalerType     = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	setterType          = reflect.TypeOf((*setter)(nil)).Elem()
+)
+
 type parseFunc func(src string, typ reflect.Type) (reflect.Value, error)
 
 var builtinTypesParsingFuncs = map[reflect.Kind]parseFunc{
 	reflect.Bool:    parseBool,
 	reflect.String:  parseString,
-	reflect.Int:     parseInt,
-	reflect.Int8:    parseInt,
-	reflect.Int16:   parseInt,
-	reflect.Int32:   parseInt,
-	reflect.Int64:   parseInt,
-	reflect.Uint:    parseUInt,
-	reflect.Uint8:   parseUInt,
-	reflect.Uint16:  parseUInt,
-	reflect.Uint32:  parseUInt,
-	reflect.Uint64:  parseUInt,
-	reflect.Float32: parseFloat,
-	reflect.Float64: parseFloat,
+	reflect.Int:     defaultNumberFormat.parseInt,
+	reflect.Int8:    defaultNumberFormat.parseInt,
+	reflect.Int16:   defaultNumberFormat.parseInt,
+	reflect.Int32:   defaultNumberFormat.parseInt,
+	reflect.Int64:   defaultNumberFormat.parseInt,
+	reflect.Uint:    defaultNumberFormat.parseUInt,
+	reflect.Uint8:   defaultNumberFormat.parseUInt,
+	reflect.Uint16:  defaultNumberFormat.parseUInt,
+	reflect.Uint32:  defaultNumberFormat.parseUInt,
+	reflect.Uint64:  defaultNumberFormat.parseUInt,
+	reflect.Uintptr: defaultNumberFormat.parseUInt,
+	reflect.Float32: defaultNumberFormat.parseFloat,
+	reflect.Float64: defaultNumberFormat.parseFloat,
 }
 
 var typesParsingFuncs = map[reflect.Type]parseFunc{
-	reflect.TypeOf(time.Second): parseDuration,
+	reflect.TypeOf(time.Second):           parseDuration,
+	reflect.TypeOf([]byte(nil)):           parseBytes,
+	reflect.TypeOf(os.FileMode(0)):        parseFileMode,
+	bigIntType:                            defaultNumberFormat.parseBigInt,
+	bigFloatType:                          defaultNumberFormat.parseBigFloat,
+	bigRatType:                            defaultNumberFormat.parseBigRat,
+	reflect.TypeOf(netip.Addr{}):          parseAddr,
+	reflect.TypeOf(netip.Prefix{}):        parsePrefix,
+	reflect.TypeOf(net.IP(nil)):           parseIP,
+	reflect.TypeOf(net.HardwareAddr(nil)): parseHardwareAddr,
+	reflect.TypeOf(url.URL{}):             parseURL,
+	reflect.TypeOf(mail.Address{}):        parseMailAddress,
+}
+
+// fileTypes are the file type characters of `ls -l` style modes
+var fileTypes = map[byte]os.FileMode{
+	'-': 0,
+	'd': os.ModeDir,
+	'l': os.ModeSymlink,
+	'p': os.ModeNamedPipe,
+	's': os.ModeSocket,
+	'c': os.ModeDevice | os.ModeCharDevice,
+	'b': os.ModeDevice,
 }
 
 func getParsingFunc(typ reflect.Type) parseFunc {
 	if parsingFunc, ok := typesParsingFuncs[typ]; ok {
 		return parsingFunc
 	}
+	if parsingFunc := getUnmarshalerParsingFunc(typ); parsingFunc != nil {
+		return parsingFunc
+	}
 	if parsingFunc, ok := builtinTypesParsingFuncs[typ.Kind()]; ok {
 		return parsingFunc
 	}
 	return nil
 }
 
-func parseString(src string, typ reflect.Type) (reflect.Value, error) {
-	return reflect.ValueOf(src).Convert(typ), nil
+// getUnmarshalerParsingFunc returns a parsing function for types which their pointer implements
+// Unmarshaler, encoding.TextUnmarshaler or a flag.Value-style Set method, in that order of preference
+func getUnmarshalerParsingFunc(typ reflect.Type) parseFunc {
+	if typ.Kind() == reflect.Ptr || isTimeType(typ) {
+		// Pointers are dereferenced before parsing, and time.Time is parsed by its layout
+		return nil
+	}
+
+	ptrType := reflect.PtrTo(typ)
+	switch {
+	case ptrType.Implements(unmarshalerType):
+		return parseUnmarshaler
+	case ptrType.Implements(textUnmarshalerType):
+		return parseTextUnmarshaler
+	case ptrType.Implements(setterType):
+		return parseSetter
+	}
+	return nil
 }
 
-func parseInt(src string, typ reflect.Type) (reflect.Value, error) {
-	n, err := strconv.ParseInt(src, 10, 64)
-	if err != nil {
+func parseUnmarshaler(src string, typ reflect.Type) (reflect.Value, error) {
+	ret := reflect.New(typ)
+	if err := ret.Interface().(Unmarshaler).UnmarshalRegroup(src); err != nil {
 		return reflect.Value{}, err
 	}
-
-	return reflect.ValueOf(n).Convert(typ), nil
+	return ret.Elem(), nil
 }
 
-func parseUInt(src string, typ reflect.Type) (reflect.Value, error) {
-	n, err := strconv.ParseUint(src, 10, 64)
-	if err != nil {
+func parseTextUnmarshaler(src string, typ reflect.Type) (reflect.Value, error) {
+	ret := reflect.New(typ)
+	if err := ret.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(src)); err != nil {
 		return reflect.Value{}, err
 	}
-
-	return reflect.ValueOf(n).Convert(typ), nil
+	return ret.Elem(), nil
 }
 
-func parseFloat(src string, typ reflect.Type) (reflect.Value, error) {
-	n, err := strconv.ParseFloat(src, 64)
-	if err != nil {
+func parseSetter(src string, typ reflect.Type) (reflect.Value, error) {
+	ret := reflect.New(typ)
+	if err := ret.Interface().(setter).Set(src); err != nil {
 		return reflect.Value{}, err
 	}
-	return reflect.ValueOf(n).Convert(typ), nil
+	return ret.Elem(), nil
+}
+
+func parseString(src string, typ reflect.Type) (reflect.Value, error) {
+	return reflect.ValueOf(src).Convert(typ), nil
+}
+
+func parseBytes(src string, _ reflect.Type) (reflect.Value, error) {
+	return reflect.ValueOf([]byte(src)), nil
 }
 
 func parseBool(src string, _ reflect.Type) (reflect.Value, error) {
@@ -84,3 +157,61 @@ func parseDuration(src string, _ reflect.Type) (reflect.Value, error) {
 	}
 	return reflect.ValueOf(d), nil
 }
+
+// parseFileMode parses an octal file mode (such as `0755`), or an `ls -l` style mode (such as `rwxr-xr-x` or `drwxr-xr-x`)
+func parseFileMode(src string, _ reflect.Type) (reflect.Value, error) {
+	if src != "" && strings.Trim(src, "01234567") == "" {
+		mode, err := strconv.ParseUint(src, 8, 32)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if mode > uint64(os.ModePerm|0o7000) {
+			return reflect.Value{}, fmt.Errorf("invalid file mode %q", src)
+		}
+		// The setuid, setgid and sticky bits of octal modes are converted to their os.FileMode bits
+		perm := os.FileMode(mode) & os.ModePerm
+		if mode&0o4000 != 0 {
+			perm |= os.ModeSetuid
+		}
+		if mode&0o2000 != 0 {
+			perm |= os.ModeSetgid
+		}
+		if mode&0o1000 != 0 {
+			perm |= os.ModeSticky
+		}
+		return reflect.ValueOf(perm), nil
+	}
+
+	// Extended attributes and ACL markers of `ls -l` are ignored
+	symbolic := strings.TrimRight(src, "+.@")
+	var mode os.FileMode
+	if len(symbolic) == 10 {
+		fileType, ok := fileTypes[symbolic[0]]
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("invalid file mode %q", src)
+		}
+		mode, symbolic = fileType, symbolic[1:]
+	}
+	if len(symbolic) != 9 {
+		return reflect.Value{}, fmt.Errorf("invalid file mode %q", src)
+	}
+
+	// specialBits are the bits set by 's' or 't' in the execute position of the user, group and other permissions
+	specialBits := [3]os.FileMode{os.ModeSetuid, os.ModeSetgid, os.ModeSticky}
+	for i := 0; i < 9; i++ {
+		bit := os.FileMode(1) << (8 - i)
+		c := symbolic[i]
+		switch {
+		case c == '-':
+		case c == "rwx"[i%3]:
+			mode |= bit
+		case i%3 == 2 && c == "sst"[i/3]:
+			mode |= bit | specialBits[i/3]
+		case i%3 == 2 && c == "SST"[i/3]:
+			mode |= specialBits[i/3]
+		default:
+			return reflect.Value{}, fmt.Errorf("invalid file mode %q", src)
+		}
+	}
+	return reflect.ValueOf(mode), nil
+}
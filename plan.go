@@ -0,0 +1,852 @@
+package regroup
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var knownOptions = map[string]bool{
+	requiredOption:  true,
+	existsOption:    true,
+	nonEmptyOption:  true,
+	splitOption:     true,
+	eachOption:      true,
+	matchOption:     true,
+	patternOption:   true,
+	prefixOption:    true,
+	layoutOption:    true,
+	locOption:       true,
+	unixOption:      true,
+	unixMsOption:    true,
+	unixNsOption:    true,
+	inferYearOption: true,
+	wrapOption:      true,
+	saturateOption:  true,
+	baseOption:      true,
+	sepOption:       true,
+	bytesOption:     true,
+	siOption:        true,
+	iecOption:       true,
+	percentOption:   true,
+}
+
+// timeOptions are the options which can be used only for time.Time fields
+var timeOptions = map[string]bool{
+	layoutOption:    true,
+	locOption:       true,
+	unixOption:      true,
+	unixMsOption:    true,
+	unixNsOption:    true,
+	inferYearOption: true,
+}
+
+// fieldPlan is the precompiled instructions for filling a single struct field
+type fieldPlan struct {
+	index int
+	name  string
+	// ptr indicates the field is a pointer to typ
+	ptr bool
+	typ reflect.Type
+
+	// nested is set for struct fields, which are filled recursively
+	nested *structPlan
+	// capture is set for capture field types (such as Optional and Captured), valueType is the type of their Value
+	capture bool
+	// repeated is set for slice and array fields, which are filled with every repetition of the group.
+	// valueType is the type of their elements, which are pointers if elemPtr is set
+	repeated bool
+	elemPtr  bool
+	// split is set for repeated fields which are filled by splitting every value of the group
+	split string
+	// mapped is set for map fields, which are filled with mapGroups, keyed by the group names.
+	// valueType is the type of their values, which are pointers if elemPtr is set.
+	// catchAll is set if mapGroups are all the groups which aren't used by other fields, resolved once the whole plan is built
+	mapped    bool
+	catchAll  bool
+	mapGroups []mapGroup
+	// sub is set for fields which are filled by matching a nested expression to the value of the group.
+	// Repeated fields are filled by every match of it, and subPlan is set for struct fields or struct elements
+	sub     *ReGroup
+	subPlan *structPlan
+	// expr is the expression the plan is built against, used to get every repetition of the group
+	expr      *expression
+	valueType reflect.Type
+
+	group string
+	// groupIdxs are the indices of all the groups named group, see matchedGroup
+	groupIdxs []int
+	required  bool
+	// exists sets a bool field to whether the group participated in the match
+	exists bool
+	// nonEmpty sets a bool field to whether the group participated in the match with a non-empty value
+	nonEmpty bool
+	// timeFormat is set for time.Time fields
+	timeFormat *timeFormat
+	// parts are the indices of the groups composing a time field, see fillComposite
+	parts [][]int
+	parse parseFunc
+}
+
+// mapGroup is a group filling a map field, key is the group name without the prefix of the field key
+type mapGroup struct {
+	name string
+	key  string
+	idxs []int
+}
+
+// structPlan is the precompiled instructions for filling a struct type from a match,
+// it is built once per type and cached in the ReGroup
+type structPlan struct {
+	fields []fieldPlan
+	// expr is the expression to match with, set for the top level plan
+	expr *expression
+	// unknownOptions are the unknown options of the fields, which are reported by Validate but ignored when matching
+	unknownOptions []error
+}
+
+// planBuilder builds plans against a single expression
+type planBuilder struct {
+	r    *ReGroup
+	expr *expression
+	// repeated is set if any of the fields is filled with every repetition of a group
+	repeated bool
+	// building are the struct types being built, to detect recursive types
+	building map[reflect.Type]bool
+	// consumed are the names of the groups used by the fields, which aren't caught by catch-all map fields
+	consumed map[string]bool
+}
+
+// isTimeType checks if given type is time.Time
+func isTimeType(typ reflect.Type) bool {
+	return typ.Name() == "Time" && typ.PkgPath() == "time"
+}
+
+// buildFieldPlan resolves a single struct field into a field plan, with prefix prepended to the names of its groups.
+// ok is false if the field isn't filled from the regex. All the problems found in the field are returned as errs
+func (b *planBuilder) buildFieldPlan(index int, fieldType reflect.StructField, prefix string) (plan fieldPlan, ok bool, errs []error) {
+	r := b.r
+	plan = fieldPlan{index: index, name: fieldType.Name, typ: fieldType.Type}
+	plan.parse = r.getParsingFunc(plan.typ)
+	if plan.parse == nil && plan.typ.Kind() == reflect.Ptr {
+		plan.ptr = true
+		plan.typ = plan.typ.Elem()
+		plan.parse = r.getParsingFunc(plan.typ)
+	}
+
+	plan.valueType = plan.typ
+	if valueType, ok := captureValueType(plan.typ); ok && plan.parse == nil {
+		plan.capture = true
+		plan.valueType = valueType
+		plan.parse = r.getParsingFunc(valueType)
+	}
+
+	if plan.parse == nil && (plan.typ.Kind() == reflect.Slice || plan.typ.Kind() == reflect.Array) {
+		plan.repeated = true
+		plan.expr = b.expr
+		plan.valueType = plan.typ.Elem()
+		plan.parse = r.getParsingFunc(plan.valueType)
+		if plan.parse == nil && plan.valueType.Kind() == reflect.Ptr {
+			plan.elemPtr = true
+			plan.valueType = plan.valueType.Elem()
+			plan.parse = r.getParsingFunc(plan.valueType)
+		}
+	}
+
+	if plan.parse == nil && plan.typ.Kind() == reflect.Map {
+		plan.mapped = true
+		plan.valueType = plan.typ.Elem()
+		plan.parse = r.getParsingFunc(plan.valueType)
+		if plan.parse == nil && plan.valueType.Kind() == reflect.Ptr {
+			plan.elemPtr = true
+			plan.valueType = plan.valueType.Elem()
+			plan.parse = r.getParsingFunc(plan.valueType)
+		}
+	}
+
+	tag, err := r.fieldTag(fieldType)
+	if err != nil {
+		return plan, false, []error{err}
+	}
+	regroupKey := tag.group
+	if regroupKey == skipKey && len(tag.options) == 0 {
+		return plan, false, nil
+	}
+	if regroupKey == "" {
+		// Fields without a group name are mapped by the naming strategy, if there's a matching group
+		regroupKey = r.fieldGroup(fieldType, b.expr, prefix)
+	} else {
+		// Every group of a composite field is prefixed
+		regroupKey = prefix + strings.ReplaceAll(regroupKey, compositeSeparator, compositeSeparator+prefix)
+	}
+	if plan.mapped {
+		if regroupKey == "" {
+			return plan, false, nil
+		}
+		return plan, true, b.buildMapFieldPlan(&plan, fieldType, regroupKey, &tag)
+	}
+
+	sub, hasSub, subErrs := r.subReGroup(fieldType, &tag, plan.repeated)
+	errs = append(errs, subErrs...)
+
+	isStruct := plan.parse == nil && !plan.capture && !plan.repeated && plan.typ.Kind() == reflect.Struct && !isTimeType(plan.typ)
+	if isStruct && !hasSub {
+		nestedPrefix, prefixErrs := nestedStructPrefix(fieldType, &tag)
+		if b.building[plan.typ] {
+			// Recursive types are filled only while a prefix makes the groups of every level different
+			if nestedPrefix == "" {
+				return plan, false, append(append(errs, prefixErrs...), &RecursiveTypeError{typ: plan.typ, fieldName: fieldType.Name})
+			}
+			if !b.hasGroupPrefix(prefix + nestedPrefix) {
+				// The recursion ends when no group has the prefix of the next level
+				return plan, false, append(errs, prefixErrs...)
+			}
+		}
+		nested, nestedErrs := b.buildStructPlan(plan.typ, prefix+nestedPrefix)
+		plan.nested = nested
+		return plan, true, append(append(errs, prefixErrs...), nestedErrs...)
+	}
+
+	if regroupKey == "" {
+		return plan, false, errs
+	}
+
+	plan.group = regroupKey
+	if plan.repeated {
+		// Only fields with a group need the repetitions expression
+		b.repeated = true
+	}
+	if parts := strings.Split(regroupKey, compositeSeparator); len(parts) > 1 {
+		for _, part := range parts {
+			b.consumed[part] = true
+			idxs := b.expr.groupIndices[part]
+			if len(idxs) == 0 {
+				errs = append(errs, &UnknownGroupError{group: part})
+			}
+			plan.parts = append(plan.parts, idxs)
+		}
+	} else {
+		b.consumed[regroupKey] = true
+		plan.groupIdxs = b.expr.groupIndices[regroupKey]
+		if len(plan.groupIdxs) == 0 {
+			errs = append(errs, &UnknownGroupError{group: regroupKey})
+		}
+	}
+
+	options := tag.options
+	if plan.parse == nil && isTimeType(plan.valueType) {
+		var timeErrs []error
+		plan.timeFormat, options, timeErrs = buildTimeFormat(fieldType, options, r.clock)
+		errs = append(errs, timeErrs...)
+	}
+	options, numberErrs := b.applyNumberFormat(&plan, fieldType, options)
+	errs = append(errs, numberErrs...)
+	if plan.parts != nil && (plan.timeFormat == nil || plan.repeated || plan.capture) {
+		// Only time fields can be composed of several groups
+		errs = append(errs, &InvalidOptionError{option: regroupKey, fieldName: fieldType.Name, typ: fieldType.Type})
+		return plan, true, errs
+	}
+
+	for _, option := range options {
+		switch {
+		case !knownOptions[option.key]:
+			errs = append(errs, &UnknownOptionError{option: option.String(), fieldName: fieldType.Name})
+		case timeOptions[option.key]:
+			errs = append(errs, &InvalidOptionError{option: option.key, fieldName: fieldType.Name, typ: fieldType.Type})
+		}
+	}
+	plan.required = tag.has(requiredOption)
+	if tag.has(prefixOption) {
+		errs = append(errs, &InvalidOptionError{option: prefixOption, fieldName: fieldType.Name, typ: fieldType.Type})
+	}
+
+	if tag.has(splitOption) {
+		split, _ := tag.value(splitOption)
+		if !plan.repeated || hasSub || split == "" {
+			errs = append(errs, &InvalidOptionError{option: splitOption, fieldName: fieldType.Name, typ: fieldType.Type})
+		}
+		plan.split = split
+	}
+
+	if hasSub && sub == nil {
+		// The field can't be resolved without its sub-expression
+		return plan, true, errs
+	}
+	if hasSub && !plan.repeated && !isStruct {
+		option := matchOption
+		if tag.has(patternOption) {
+			option = patternOption
+		}
+		errs = append(errs, &InvalidOptionError{option: option, fieldName: fieldType.Name, typ: fieldType.Type})
+	}
+
+	if sub != nil {
+		plan.sub = sub
+		if isStruct || (plan.parse == nil && plan.valueType.Kind() == reflect.Struct && !isTimeType(plan.valueType)) {
+			subPlan, subErrs := sub.plan(plan.valueType)
+			plan.subPlan = subPlan
+			return plan, true, append(errs, subErrs...)
+		}
+	}
+
+	plan.exists = tag.has(existsOption)
+	plan.nonEmpty = tag.has(nonEmptyOption)
+	if plan.exists || plan.nonEmpty {
+		if plan.typ.Kind() != reflect.Bool {
+			option := existsOption
+			if plan.nonEmpty {
+				option = nonEmptyOption
+			}
+			errs = append(errs, &InvalidOptionError{option: option, fieldName: fieldType.Name, typ: fieldType.Type})
+		}
+		return plan, true, errs
+	}
+
+	if plan.parse == nil && plan.timeFormat == nil {
+		errs = append(errs, &TypeNotParsableError{plan.valueType})
+	}
+	return plan, true, errs
+}
+
+// buildMapFieldPlan resolves a map field with the key `<prefix>*`, which is filled with every group starting with the prefix.
+// The key `*` catches all the groups which aren't used by other fields
+func (b *planBuilder) buildMapFieldPlan(plan *fieldPlan, fieldType reflect.StructField, key string, tag *fieldTag) (errs []error) {
+	plan.group = key
+	if !strings.HasSuffix(key, mapWildcard) || plan.typ.Key().Kind() != reflect.String {
+		return []error{&TypeNotParsableError{plan.typ}}
+	}
+	if plan.parse == nil {
+		errs = append(errs, &TypeNotParsableError{plan.valueType})
+	}
+
+	options, numberErrs := b.applyNumberFormat(plan, fieldType, tag.options)
+	errs = append(errs, numberErrs...)
+	for _, option := range options {
+		switch {
+		case option.key == requiredOption:
+			plan.required = true
+		case knownOptions[option.key]:
+			errs = append(errs, &InvalidOptionError{option: option.key, fieldName: fieldType.Name, typ: fieldType.Type})
+		default:
+			errs = append(errs, &UnknownOptionError{option: option.String(), fieldName: fieldType.Name})
+		}
+	}
+
+	prefix := strings.TrimSuffix(key, mapWildcard)
+	if prefix == "" {
+		plan.catchAll = true
+		return errs
+	}
+
+	for name, idxs := range b.expr.groupIndices {
+		if strings.HasPrefix(name, prefix) {
+			b.consumed[name] = true
+			plan.mapGroups = append(plan.mapGroups, mapGroup{name: name, key: strings.TrimPrefix(name, prefix), idxs: idxs})
+		}
+	}
+	if len(plan.mapGroups) == 0 {
+		errs = append(errs, &UnknownGroupError{group: key})
+	}
+	return errs
+}
+
+// resolveCatchAll sets the groups of the catch-all map fields in the plan to all the groups which weren't consumed by other fields
+func (b *planBuilder) resolveCatchAll(plan *structPlan) {
+	for i := range plan.fields {
+		field := &plan.fields[i]
+		if field.nested != nil {
+			b.resolveCatchAll(field.nested)
+		}
+		if !field.catchAll {
+			continue
+		}
+		field.mapGroups = nil
+		for name, idxs := range b.expr.groupIndices {
+			if !b.consumed[name] {
+				field.mapGroups = append(field.mapGroups, mapGroup{name: name, key: name, idxs: idxs})
+			}
+		}
+	}
+}
+
+// hasGroupPrefix checks if any group name starts with prefix. Case is ignored, as the naming strategy may ignore it
+func (b *planBuilder) hasGroupPrefix(prefix string) bool {
+	prefix = strings.ToLower(prefix)
+	for name := range b.expr.groupIndices {
+		if strings.HasPrefix(strings.ToLower(name), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// nestedStructPrefix returns the `prefix=<prefix>` option of a nested struct field, prepended to the names of all the groups inside it.
+// The group name in the tag of a nested struct field is ignored, and prefix is its only valid option
+func nestedStructPrefix(fieldType reflect.StructField, tag *fieldTag) (prefix string, errs []error) {
+	for _, option := range tag.options {
+		switch {
+		case option.key == prefixOption:
+			prefix = option.value
+		case knownOptions[option.key]:
+			errs = append(errs, &InvalidOptionError{option: option.key, fieldName: fieldType.Name, typ: fieldType.Type})
+		default:
+			errs = append(errs, &UnknownOptionError{option: option.String(), fieldName: fieldType.Name})
+		}
+	}
+	return prefix, errs
+}
+
+// subReGroup returns the nested ReGroup which is matched to the value of the group, given by one of the options:
+// `each=<regex>` for repeated fields, `match=<regex>` for struct fields, or `pattern=<name>` for both,
+// referring to a ReGroup registered with WithPattern
+func (r *ReGroup) subReGroup(fieldType reflect.StructField, tag *fieldTag, repeated bool) (sub *ReGroup, ok bool, errs []error) {
+	each, _ := tag.value(eachOption)
+	matchExpr, _ := tag.value(matchOption)
+	pattern, _ := tag.value(patternOption)
+	hasEach, hasMatch, hasPattern := tag.has(eachOption), tag.has(matchOption), tag.has(patternOption)
+
+	invalid := func(option string) {
+		errs = append(errs, &InvalidOptionError{option: option, fieldName: fieldType.Name, typ: fieldType.Type})
+	}
+	switch {
+	case hasEach && (!repeated || hasMatch || hasPattern):
+		invalid(eachOption)
+	case hasMatch && (repeated || hasPattern):
+		invalid(matchOption)
+	case hasEach || hasMatch:
+		expr := each
+		if hasMatch {
+			expr = matchExpr
+		}
+		var err error
+		if sub, err = r.compileSub(expr); err != nil {
+			errs = append(errs, err)
+		}
+	case hasPattern:
+		if sub = r.patterns[pattern]; sub == nil {
+			errs = append(errs, &UnknownPatternError{pattern: pattern, fieldName: fieldType.Name})
+		}
+	}
+	return sub, hasEach || hasMatch || hasPattern, errs
+}
+
+// buildStructPlan walks the struct type once and resolves all of its fields into a plan.
+// All the problems found in the struct are returned together
+func (b *planBuilder) buildStructPlan(typ reflect.Type, prefix string) (*structPlan, []error) {
+	if !b.building[typ] {
+		b.building[typ] = true
+		defer delete(b.building, typ)
+	}
+
+	plan := &structPlan{}
+	var errs []error
+	for i := 0; i < typ.NumField(); i++ {
+		fieldType := typ.Field(i)
+		if fieldType.PkgPath != "" {
+			// Unexported fields can't be set
+			continue
+		}
+
+		field, ok, fieldErrs := b.buildFieldPlan(i, fieldType, prefix)
+		errs = append(errs, fieldErrs...)
+		if ok {
+			plan.fields = append(plan.fields, field)
+		}
+	}
+	return plan, errs
+}
+
+// buildPlan builds the plan for given struct type.
+// Plans with fields filled by every repetition of a group are built against the repetitions expression
+func (r *ReGroup) buildPlan(typ reflect.Type) (*structPlan, []error) {
+	b := &planBuilder{r: r, expr: r.expression, building: make(map[reflect.Type]bool), consumed: make(map[string]bool)}
+	plan, errs := b.buildStructPlan(typ, "")
+	if len(fatalErrors(errs)) > 0 || !b.repeated {
+		b.resolveCatchAll(plan)
+		plan.expr = b.expr
+		return plan, errs
+	}
+
+	repetitions, err := r.repetitionsExpression()
+	if err != nil {
+		return nil, []error{&CompileError{err: err}}
+	}
+	b = &planBuilder{r: r, expr: repetitions, building: make(map[reflect.Type]bool), consumed: make(map[string]bool)}
+	plan, errs = b.buildStructPlan(typ, "")
+	b.resolveCatchAll(plan)
+	plan.expr = b.expr
+	return plan, errs
+}
+
+// fatalErrors returns the problems which prevent filling the struct, which are all but unknown options
+func fatalErrors(errs []error) (fatal []error) {
+	for _, err := range errs {
+		if _, ok := err.(*UnknownOptionError); !ok {
+			fatal = append(fatal, err)
+		}
+	}
+	return fatal
+}
+
+// plan returns the cached plan for given struct type, building it on first use, along with all of its problems.
+// Invalid types aren't cached and their plan is nil, but unknown options don't invalidate the type
+func (r *ReGroup) plan(typ reflect.Type) (*structPlan, []error) {
+	if cached, ok := r.plans.Load(typ); ok {
+		plan := cached.(*structPlan)
+		return plan, plan.unknownOptions
+	}
+
+	plan, errs := r.buildPlan(typ)
+	if len(fatalErrors(errs)) > 0 {
+		return nil, errs
+	}
+	plan.unknownOptions = errs
+	actual, _ := r.plans.LoadOrStore(typ, plan)
+	plan = actual.(*structPlan)
+	return plan, plan.unknownOptions
+}
+
+// targetPlan returns the plan for the struct type of given target reference.
+// Only the first problem found is returned and unknown options are ignored, use Validate to get all of them
+func (r *ReGroup) targetPlan(targetRef reflect.Value) (*structPlan, error) {
+	if targetRef.Kind() != reflect.Struct {
+		return nil, &NotStructPtrError{}
+	}
+	plan, errs := r.plan(targetRef.Type())
+	if plan == nil {
+		return nil, errs[0]
+	}
+	return plan, nil
+}
+
+// participated checks if group index i participated in the match, even if it matched an empty string
+func participated(match []int, i int) bool {
+	return match[2*i] >= 0
+}
+
+// matchedGroup returns the index of the group which participated in the match out of the indices of groups with the same name.
+// Go allows the same name in several alternation branches, which are treated as one logical group.
+// If none of them participated, the first index is returned
+func matchedGroup(match []int, idxs []int) int {
+	for _, i := range idxs {
+		if participated(match, i) {
+			return i
+		}
+	}
+	return idxs[0]
+}
+
+// group returns the matched value of group index i from the submatch indices,
+// or an empty string if the group didn't participate in the match
+func group(s string, match []int, i int) string {
+	if !participated(match, i) {
+		return ""
+	}
+	return s[match[2*i]:match[2*i+1]]
+}
+
+// fill sets the field value from the submatch indices of s
+func (f *fieldPlan) fill(s string, match []int, fieldRef reflect.Value) error {
+	if f.ptr {
+		if fieldRef.IsNil() {
+			// Nil pointers are allocated only if any of their groups participated in the match
+			if !f.participated(match) {
+				if f.required {
+					return &RequiredGroupIsEmpty{groupName: f.group, fieldName: f.name}
+				}
+				return nil
+			}
+			fieldRef.Set(reflect.New(f.typ))
+		}
+		fieldRef = fieldRef.Elem()
+	}
+
+	if f.nested != nil {
+		return f.nested.fill(s, match, fieldRef)
+	}
+
+	if f.repeated {
+		return f.fillRepeated(s, match, fieldRef)
+	}
+
+	if f.mapped {
+		return f.fillMap(s, match, fieldRef)
+	}
+
+	if f.parts != nil {
+		return f.fillComposite(s, match, fieldRef)
+	}
+
+	groupIdx := matchedGroup(match, f.groupIdxs)
+	matchedVal := group(s, match, groupIdx)
+	if f.subPlan != nil {
+		return f.fillSub(matchedVal, fieldRef)
+	}
+
+	if f.exists {
+		fieldRef.SetBool(participated(match, groupIdx))
+		return nil
+	}
+
+	if f.nonEmpty {
+		fieldRef.SetBool(matchedVal != "")
+		return nil
+	}
+
+	if matchedVal == "" && f.required {
+		return &RequiredGroupIsEmpty{groupName: f.group, fieldName: f.name}
+	}
+
+	if f.capture {
+		return f.fillCapture(matchedVal, match[2*groupIdx], match[2*groupIdx+1], fieldRef)
+	}
+
+	if matchedVal == "" {
+		return nil
+	}
+
+	parsed, err := f.parseValue(matchedVal)
+	if err != nil {
+		return &ParseError{group: f.group, err: err}
+	}
+
+	fieldRef.Set(parsed)
+	return nil
+}
+
+// participated checks if any of the groups filling the field participated in the match
+func (f *fieldPlan) participated(match []int) bool {
+	switch {
+	case f.nested != nil:
+		return f.nested.participated(match)
+	case f.mapped:
+		for _, g := range f.mapGroups {
+			if participated(match, matchedGroup(match, g.idxs)) {
+				return true
+			}
+		}
+		return false
+	case f.parts != nil:
+		for _, idxs := range f.parts {
+			if participated(match, matchedGroup(match, idxs)) {
+				return true
+			}
+		}
+		return false
+	}
+	return participated(match, matchedGroup(match, f.groupIdxs))
+}
+
+// fillComposite sets a time field from the non-empty values of its groups, joined by a space
+func (f *fieldPlan) fillComposite(s string, match []int, fieldRef reflect.Value) error {
+	var values []string
+	for _, idxs := range f.parts {
+		if value := group(s, match, matchedGroup(match, idxs)); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	if len(values) == 0 {
+		if f.required {
+			return &RequiredGroupIsEmpty{groupName: f.group, fieldName: f.name}
+		}
+		return nil
+	}
+
+	parsed, err := f.parseValue(strings.Join(values, " "))
+	if err != nil {
+		return &ParseError{group: f.group, err: err}
+	}
+	fieldRef.Set(parsed)
+	return nil
+}
+
+// fillSub sets a struct field by matching the nested expression to the value of the group.
+// The field is left untouched if the group is empty or the nested expression doesn't match it, unless it's required
+func (f *fieldPlan) fillSub(matchedVal string, fieldRef reflect.Value) error {
+	if matchedVal == "" && f.required {
+		return &RequiredGroupIsEmpty{groupName: f.group, fieldName: f.name}
+	}
+
+	match := f.subPlan.expr.matcher.FindStringSubmatchIndex(matchedVal)
+	if match == nil {
+		if f.required {
+			return &ParseError{group: f.group, err: &NoMatchFoundError{}}
+		}
+		return nil
+	}
+	return f.subPlan.fill(matchedVal, match, fieldRef)
+}
+
+// fillRepeated sets a slice or array field with the parsed elements of every repetition of the group.
+// Empty elements are skipped
+func (f *fieldPlan) fillRepeated(s string, match []int, fieldRef reflect.Value) error {
+	var values []reflect.Value
+	index := 0
+	matchedVals, err := f.expr.groupValues(s, match, f.group)
+	if err != nil {
+		return &ParseError{group: f.group, err: err}
+	}
+	for _, matchedVal := range matchedVals {
+		if values, index, err = f.appendElements(values, matchedVal, index); err != nil {
+			return &ParseError{group: f.group, err: fmt.Errorf("element %d: %w", index, err)}
+		}
+	}
+
+	if len(values) == 0 {
+		if f.required {
+			return &RequiredGroupIsEmpty{groupName: f.group, fieldName: f.name}
+		}
+		return nil
+	}
+
+	if fieldRef.Kind() == reflect.Array {
+		if len(values) > fieldRef.Len() {
+			return &ParseError{group: f.group, err: fmt.Errorf("%d values don't fit in array of length %d", len(values), fieldRef.Len())}
+		}
+		// Elements of a previous match beyond the values are cleared
+		fieldRef.Set(reflect.Zero(f.typ))
+	} else {
+		fieldRef.Set(reflect.MakeSlice(f.typ, len(values), len(values)))
+	}
+	for i, value := range values {
+		fieldRef.Index(i).Set(value)
+	}
+	return nil
+}
+
+// fillMap sets a map field with the parsed values of its groups which participated in the match.
+// Empty values are set as the zero value, and the field is left untouched if none of the groups participated
+func (f *fieldPlan) fillMap(s string, match []int, fieldRef reflect.Value) error {
+	var m reflect.Value
+	for _, g := range f.mapGroups {
+		i := matchedGroup(match, g.idxs)
+		if !participated(match, i) {
+			continue
+		}
+
+		value := reflect.Zero(f.valueType)
+		if matchedVal := group(s, match, i); matchedVal != "" {
+			parsed, err := f.parseValue(matchedVal)
+			if err != nil {
+				return &ParseError{group: g.name, err: err}
+			}
+			value = parsed
+		}
+		if f.elemPtr {
+			ptr := reflect.New(f.valueType)
+			ptr.Elem().Set(value)
+			value = ptr
+		}
+
+		if !m.IsValid() {
+			m = reflect.MakeMapWithSize(f.typ, len(f.mapGroups))
+		}
+		m.SetMapIndex(reflect.ValueOf(g.key).Convert(f.typ.Key()), value)
+	}
+
+	if !m.IsValid() {
+		if f.required {
+			return &RequiredGroupIsEmpty{groupName: f.group, fieldName: f.name}
+		}
+		return nil
+	}
+	fieldRef.Set(m)
+	return nil
+}
+
+// appendElements parses the elements of a single group value and appends them to values.
+// index is the position of the first element among all the elements of the field, including the empty ones.
+// It returns the position after the last element, or the position of the failed element on error
+func (f *fieldPlan) appendElements(values []reflect.Value, matchedVal string, index int) ([]reflect.Value, int, error) {
+	if f.subPlan != nil {
+		for _, match := range f.subPlan.expr.matcher.FindAllStringSubmatchIndex(matchedVal, -1) {
+			elem := reflect.New(f.valueType)
+			if err := f.subPlan.fill(matchedVal, match, elem.Elem()); err != nil {
+				return values, index, err
+			}
+			if !f.elemPtr {
+				elem = elem.Elem()
+			}
+			values = append(values, elem)
+			index++
+		}
+		return values, index, nil
+	}
+
+	elems := []string{matchedVal}
+	switch {
+	case f.split != "":
+		elems = strings.Split(matchedVal, f.split)
+	case f.sub != nil:
+		elems = f.sub.matcher.FindAllString(matchedVal, -1)
+	}
+
+	for i, elem := range elems {
+		if f.split != "" {
+			elem = strings.TrimSpace(elem)
+		}
+		if elem == "" {
+			continue
+		}
+		parsed, err := f.parseValue(elem)
+		if err != nil {
+			return values, index + i, err
+		}
+		if f.elemPtr {
+			ptr := reflect.New(f.valueType)
+			ptr.Elem().Set(parsed)
+			parsed = ptr
+		}
+		values = append(values, parsed)
+	}
+	return values, index + len(elems), nil
+}
+
+// fillCapture sets a capture field type with the parsed value and the submatch details of the group
+func (f *fieldPlan) fillCapture(matchedVal string, start, end int, fieldRef reflect.Value) error {
+	value := reflect.Zero(f.valueType)
+	if matchedVal != "" {
+		parsed, err := f.parseValue(matchedVal)
+		if err != nil {
+			return &ParseError{group: f.group, err: err}
+		}
+		value = parsed
+	}
+
+	fieldRef.Addr().Interface().(capture).setCapture(value, matchedVal, start, end)
+	return nil
+}
+
+// parseValue parses the matched value into the field value type
+func (f *fieldPlan) parseValue(matchedVal string) (reflect.Value, error) {
+	if f.timeFormat != nil {
+		parsed, err := f.timeFormat.parse(matchedVal)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(parsed), nil
+	}
+	return f.parse(matchedVal, f.valueType)
+}
+
+// participated checks if any of the groups filling the plan fields participated in the match
+func (p *structPlan) participated(match []int) bool {
+	for i := range p.fields {
+		if p.fields[i].participated(match) {
+			return true
+		}
+	}
+	return false
+}
+
+// fill executes the plan over the submatch indices of s, setting all the planned fields of targetRef
+func (p *structPlan) fill(s string, match []int, targetRef reflect.Value) error {
+	for i := range p.fields {
+		field := &p.fields[i]
+		if err := field.fill(s, match, targetRef.Field(field.index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
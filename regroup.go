@@ -1,26 +1,55 @@
 package regroup
 
 import (
-	"fmt"
 	"reflect"
 	"regexp"
+	"regexp/syntax"
 	"strconv"
-	"strings"
+	"sync"
 	"time"
-
-	"golang.org/x/exp/slices"
 )
 
 const (
 	requiredOption = "required"
 	existsOption   = "exists"
+	nonEmptyOption = "nonempty"
+	splitOption    = "split"
+	eachOption     = "each"
+	matchOption    = "match"
+	patternOption  = "pattern"
+	prefixOption   = "prefix"
+	// skipKey skips the field, even if it matches a group by the naming strategy
+	skipKey = "-"
+	// compositeSeparator separates the groups of a time field composed of several groups, such as `date+time`
+	compositeSeparator = "+"
+	// mapWildcard ends the key of map fields, see buildMapFieldPlan
+	mapWildcard = "*"
 )
 
 // ReGroup is the main ReGroup matcher struct
 type ReGroup struct {
-	matcher *regexp.Regexp
+	*expression
+	// repetitions is the expression used by plans with fields filled by every repetition of a group, built on first use
+	repetitionsOnce sync.Once
+	repetitions     *expression
+	repetitionsErr  error
+	// opts are the options the ReGroup was compiled with, inherited by the sub-expressions of struct tags
+	opts []Option
+	// converters are the converters registered to this ReGroup only, see WithConverter
+	converters map[reflect.Type]parseFunc
+	// naming maps fields without a group name to groups, see WithNaming
+	naming NamingStrategy
+	// clock returns the reference time for inferring missing years, see WithClock
+	clock func() time.Time
+	// patterns are the named sub-expressions which can be referred to by struct tags, see WithPattern
+	patterns map[string]*ReGroup
+	// plans caches the compiled plan of every struct type used as a target (reflect.Type -> *structPlan)
+	plans sync.Map
 }
 
+// Option configures a ReGroup on compilation
+type Option func(r *ReGroup)
+
 func quote(s string) string {
 	if strconv.CanBackquote(s) {
 		return "`" + s + "`"
@@ -30,152 +59,74 @@ func quote(s string) string {
 
 // Compile compiles given expression as regex and return new ReGroup with this expression as matching engine.
 // If the expression can't be compiled as regex, a CompileError will be returned
-func Compile(expr string) (*ReGroup, error) {
+func Compile(expr string, opts ...Option) (*ReGroup, error) {
 	matcher, err := regexp.Compile(expr)
 	if err != nil {
 		return nil, &CompileError{err: err}
 	}
 
-	return &ReGroup{matcher: matcher}, nil
+	r := &ReGroup{expression: newExpression(matcher), opts: opts, clock: time.Now}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
 }
 
 // MustCompile calls Compile and panics if it returns an error
-func MustCompile(expr string) *ReGroup {
-	reGroup, err := Compile(expr)
+func MustCompile(expr string, opts ...Option) *ReGroup {
+	reGroup, err := Compile(expr, opts...)
 	if err != nil {
 		panic(`regroup: Compile(` + quote(expr) + `): ` + err.Error())
 	}
 	return reGroup
 }
 
-// matchGroupMap converts the match string array into a map of group keys to group values
-func (r *ReGroup) matchGroupMap(match []string) map[string]string {
-	ret := make(map[string]string)
-	for i, name := range r.matcher.SubexpNames() {
-		if i != 0 && name != "" {
-			ret[name] = match[i]
-		}
-	}
-	return ret
-}
-
-// groupAndOption returns the requested regroup and its options split by ','
-func (r *ReGroup) groupAndOption(fieldType reflect.StructField) (group string, option []string) {
-	regroupKey := fieldType.Tag.Get("regroup")
-	if regroupKey == "" {
-		return "", nil
-	}
-	split := strings.Split(regroupKey, ",")
-	if len(split) == 1 {
-		return strings.TrimSpace(split[0]), nil
-	}
-	var options []string
-	for _, opt := range split[1:] {
-		options = append(options, strings.TrimSpace(opt))
-	}
-	return strings.TrimSpace(split[0]), options
+// compileSub compiles an expression given in a struct tag, with the same options as the ReGroup
+func (r *ReGroup) compileSub(expr string) (*ReGroup, error) {
+	return Compile(expr, r.opts...)
 }
 
-// setField getting a single struct field and matching groups map and set the field value to its matching group value tag
-// after parsing it to match the field type
-func (r *ReGroup) setField(fieldType reflect.StructField, fieldRef reflect.Value, matchGroup map[string]string) error {
-	fieldRefType := fieldType.Type
-	ptr := false
-	if fieldRefType.Kind() == reflect.Ptr {
-		ptr = true
-		fieldRefType = fieldType.Type.Elem()
-	}
-
-	if fieldRefType.Kind() == reflect.Struct {
-		if ptr {
-			if fieldRef.IsNil() {
-				return fmt.Errorf("can't set value to nil pointer in struct field: %s", fieldType.Name)
-			}
-			fieldRef = fieldRef.Elem()
-		}
-		if fieldRefType.Name() == "Time" && fieldRefType.PkgPath() == "time" {
-			return r.fillTimeTarget(fieldType, fieldRef, matchGroup)
+// WithPattern is a compile option registering a named sub-expression, which struct tags can refer to with the `pattern=<name>` option.
+// The sub-expression is matched to the value of the field group, using its own options
+func WithPattern(name string, sub *ReGroup) Option {
+	return func(r *ReGroup) {
+		if r.patterns == nil {
+			r.patterns = make(map[string]*ReGroup)
 		}
-		return r.fillTarget(matchGroup, fieldRef)
-	}
-
-	regroupKey, regroupOptions := r.groupAndOption(fieldType)
-	if regroupKey == "" {
-		return nil
+		r.patterns[name] = sub
 	}
+}
 
-	if ptr {
-		if fieldRef.IsNil() {
-			return fmt.Errorf("can't set value to nil pointer in field: %s", fieldType.Name)
+// repetitionsExpression returns the expression which can report every repetition of the named groups, see compileRepetitions.
+// If there are no repetitions containing named groups, the ReGroup expression is returned
+func (r *ReGroup) repetitionsExpression() (*expression, error) {
+	r.repetitionsOnce.Do(func() {
+		tree, err := syntax.Parse(r.matcher.String(), syntax.Perl)
+		if err != nil {
+			r.repetitionsErr = err
+			return
 		}
-		fieldRef = fieldRef.Elem()
-	}
-
-	matchedVal, ok := matchGroup[regroupKey]
-	if !ok {
-		return &UnknownGroupError{group: regroupKey}
-	}
-
-	if slices.Contains(regroupOptions, existsOption) {
-		fieldRef.SetBool(matchedVal != "")
-		return nil
-	}
-
-	if matchedVal == "" {
-		if slices.Contains(regroupOptions, requiredOption) {
-			return &RequiredGroupIsEmpty{groupName: regroupKey, fieldName: fieldType.Name}
+		r.repetitions, r.repetitionsErr = compileRepetitions(tree)
+		if r.repetitionsErr == nil && len(r.repetitions.repetitions) == 0 {
+			r.repetitions = r.expression
 		}
-		return nil
-	}
-
-	parsedFunc := getParsingFunc(fieldRefType)
-	if parsedFunc == nil {
-		return &TypeNotParsableError{fieldRefType}
-	}
-
-	parsed, err := parsedFunc(matchedVal, fieldRefType)
-	if err != nil {
-		return &ParseError{group: regroupKey, err: err}
-	}
-
-	fieldRef.Set(parsed)
-
-	return nil
+	})
+	return r.repetitions, r.repetitionsErr
 }
 
-func (r *ReGroup) fillTarget(matchGroup map[string]string, targetRef reflect.Value) error {
-	targetType := targetRef.Type()
-	for i := 0; i < targetType.NumField(); i++ {
-		fieldRef := targetRef.Field(i)
-		if !fieldRef.CanSet() {
+// matchGroupMap converts the submatch indices into a map of group keys to group values.
+// Groups with the same name are treated as one logical group, valued by the participating one.
+// If omitAbsent is true, groups which didn't participate in the match are omitted
+func (r *ReGroup) matchGroupMap(s string, match []int, omitAbsent bool) map[string]string {
+	ret := make(map[string]string, len(r.groupIndices))
+	for name, idxs := range r.groupIndices {
+		i := matchedGroup(match, idxs)
+		if omitAbsent && !participated(match, i) {
 			continue
 		}
-
-		if err := r.setField(targetType.Field(i), fieldRef, matchGroup); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-func (r *ReGroup) fillTimeTarget(fieldType reflect.StructField, fieldRef reflect.Value, matchGroup map[string]string) error {
-	regroupKey, regroupOptions := r.groupAndOption(fieldType)
-	if regroupKey == "" {
-		return nil
-	}
-	var pattern string
-	if len(regroupOptions) == 0 {
-		pattern = time.RFC3339
-	} else {
-		pattern = regroupOptions[0]
-	}
-	parsed, err := time.Parse(pattern, matchGroup[regroupKey])
-	if err != nil {
-		return err
+		ret[name] = group(s, match, i)
 	}
-	fieldRef.Set(reflect.ValueOf(parsed))
-	return nil
+	return ret
 }
 
 // validateTarget checks that given interface is a pointer of struct
@@ -189,52 +140,51 @@ func (r *ReGroup) validateTarget(target interface{}) (reflect.Value, error) {
 
 // Groups returns a map contains each group name as a key and the group's matched value as value
 func (r *ReGroup) Groups(s string) (map[string]string, error) {
-	match := r.matcher.FindStringSubmatch(s)
+	match := r.matcher.FindStringSubmatchIndex(s)
 	if match == nil {
 		return nil, &NoMatchFoundError{}
 	}
 
-	return r.matchGroupMap(match), nil
+	return r.matchGroupMap(s, match, false), nil
 }
 
-// MatchToTarget matches a regex expression to string s and parse it into `target` argument.
-// If no matches found, a &NoMatchFoundError error will be returned
-func (r *ReGroup) MatchToTarget(s string, target interface{}) error {
-	match := r.matcher.FindStringSubmatch(s)
+// MatchedGroups is like Groups, but omits the groups which didn't participate in the match.
+// Groups which participated in the match with an empty value are included
+func (r *ReGroup) MatchedGroups(s string) (map[string]string, error) {
+	match := r.matcher.FindStringSubmatchIndex(s)
 	if match == nil {
-		return &NoMatchFoundError{}
+		return nil, &NoMatchFoundError{}
 	}
 
+	return r.matchGroupMap(s, match, true), nil
+}
+
+// MatchToTarget matches a regex expression to string s and parse it into `target` argument.
+// If no matches found, a &NoMatchFoundError error will be returned
+func (r *ReGroup) MatchToTarget(s string, target interface{}) error {
 	targetRef, err := r.validateTarget(target)
 	if err != nil {
-		return err
+		return r.matchError(s, err)
+	}
+	plan, err := r.targetPlan(targetRef)
+	if err != nil {
+		return r.matchError(s, err)
 	}
-	return r.fillTarget(r.matchGroupMap(match), targetRef)
-}
-
-// Creating a new pointer to given target type
-// Will recurse over all NOT NIL struct pointer and create them too
-func (r *ReGroup) newTargetType(originalRef reflect.Value) reflect.Value {
-	originalType := originalRef.Type()
 
-	target := reflect.New(originalRef.Type()).Elem()
-	for i := 0; i < originalRef.NumField(); i++ {
-		origFieldRef := originalRef.Field(i)
-		if originalType.Field(i).Type.Kind() == reflect.Ptr {
-			if origFieldRef.IsNil() || !target.Field(i).CanSet() {
-				continue
-			}
-			origElem := origFieldRef.Elem()
-			if origElem.Type().Kind() == reflect.Struct {
-				// If the type is not nil struct pointer, recurse into it to create all necessary fields inside
-				target.Field(i).Set(r.newTargetType(origElem).Addr())
-			} else {
-				target.Field(i).Set(reflect.New(origElem.Type()))
-			}
+	match := plan.expr.matcher.FindStringSubmatchIndex(s)
+	if match == nil {
+		return &NoMatchFoundError{}
+	}
+	return plan.fill(s, match, targetRef)
+}
 
-		}
+// matchError returns a NoMatchFoundError if s doesn't match, or err otherwise.
+// A string which doesn't match is reported before the problems of the target, which Bind and Validate report up front
+func (r *ReGroup) matchError(s string, err error) error {
+	if !r.matcher.MatchString(s) {
+		return &NoMatchFoundError{}
 	}
-	return target
+	return err
 }
 
 // MatchAllToTarget will find all the regex matches for given string 's',
@@ -244,18 +194,23 @@ func (r *ReGroup) newTargetType(originalRef reflect.Value) reflect.Value {
 func (r *ReGroup) MatchAllToTarget(s string, n int, targetType interface{}) ([]interface{}, error) {
 	targetRefType, err := r.validateTarget(targetType)
 	if err != nil {
-		return nil, err
+		return nil, r.matchError(s, err)
+	}
+
+	plan, err := r.targetPlan(targetRefType)
+	if err != nil {
+		return nil, r.matchError(s, err)
 	}
 
-	matches := r.matcher.FindAllStringSubmatch(s, n)
+	matches := plan.expr.matcher.FindAllStringSubmatchIndex(s, n)
 	if matches == nil {
 		return nil, &NoMatchFoundError{}
 	}
 
 	ret := make([]interface{}, len(matches))
 	for i, match := range matches {
-		target := r.newTargetType(targetRefType)
-		if err := r.fillTarget(r.matchGroupMap(match), target); err != nil {
+		target := reflect.New(targetRefType.Type()).Elem()
+		if err := plan.fill(s, match, target); err != nil {
 			return nil, err
 		}
 		ret[i] = target.Addr().Interface()
@@ -2,8 +2,10 @@ package regroup
 
 import (
 	"fmt"
+	"net/netip"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -213,10 +215,10 @@ func TestMatchToTarget(t *testing.T) {
 			differentRe: differentRe{re: "invlid[", shouldPanic: true, mustCompile: true},
 		},
 		{
-			name:     "Including struct pointer nil field",
-			s:        "5s 123 foo",
-			wantErr:  fmt.Errorf("can't set value to nil pointer in field"),
-			expected: &IncludingPointers{},
+			name:        "Including struct pointer nil field",
+			s:           "5s foo",
+			expected:    &IncludingPointers{Str: "foo", Single: &Single{Duration: 5 * time.Second}},
+			differentRe: differentRe{re: `(?P<duration>\S+)\s+(?:(?P<num>\d+)\s+)?(?P<str>.*)`},
 		},
 	}
 	for _, tt := range tests {
@@ -360,9 +362,12 @@ func TestMatchAllToTarget(t *testing.T) {
 
 func TestBooleanExistenceCheck(t *testing.T) {
 	type Exist struct {
-		IsAdmin bool `regroup:"is_admin,exists"`
+		IsAdmin     bool `regroup:"is_admin,exists"`
+		HasAdmin    bool `regroup:"is_admin,nonempty"`
+		HasSuffix   bool `regroup:"suffix,exists"`
+		EmptySuffix bool `regroup:"suffix,nonempty"`
 	}
-	r := MustCompile(`^(?P<name>\w*)(?:,(?P<is_admin>admin))?$`)
+	r := MustCompile(`^(?P<name>\w*)(?:,(?P<is_admin>admin|))?(?:;(?P<suffix>\w*))?$`)
 	tests := map[string]struct {
 		input      string
 		assertions func(t *testing.T, parsed *Exist, err error)
@@ -372,6 +377,7 @@ func TestBooleanExistenceCheck(t *testing.T) {
 			assertions: func(t *testing.T, parsed *Exist, err error) {
 				assert.NoError(t, err)
 				assert.True(t, parsed.IsAdmin)
+				assert.True(t, parsed.HasAdmin)
 			},
 		},
 		"misspelled flag": {
@@ -385,6 +391,23 @@ func TestBooleanExistenceCheck(t *testing.T) {
 			assertions: func(t *testing.T, parsed *Exist, err error) {
 				assert.NoError(t, err)
 				assert.False(t, parsed.IsAdmin)
+				assert.False(t, parsed.HasAdmin)
+			},
+		},
+		"empty flag": {
+			input: "bob_smith,",
+			assertions: func(t *testing.T, parsed *Exist, err error) {
+				assert.NoError(t, err)
+				assert.True(t, parsed.IsAdmin)
+				assert.False(t, parsed.HasAdmin)
+			},
+		},
+		"empty suffix": {
+			input: "bob_smith;",
+			assertions: func(t *testing.T, parsed *Exist, err error) {
+				assert.NoError(t, err)
+				assert.True(t, parsed.HasSuffix)
+				assert.False(t, parsed.EmptySuffix)
 			},
 		},
 	}
@@ -397,6 +420,56 @@ func TestBooleanExistenceCheck(t *testing.T) {
 	}
 }
 
+func TestExistenceOptionOnNonBool(t *testing.T) {
+	type NonBool struct {
+		Name string `regroup:"name,nonempty"`
+	}
+	r := MustCompile(`^(?P<name>\w*)$`)
+	isErrorMatch(t, &InvalidOptionError{}, r.MatchToTarget("bob", &NonBool{}))
+}
+
+func TestMatchedGroups(t *testing.T) {
+	r := MustCompile(`(?P<duration>.*?)\s+(?P<num>\d+)(?:\s+(?P<str>[^;]*))?(?:;(?P<opt>\w*))?$`)
+	tests := []struct {
+		name     string
+		s        string
+		wantErr  error
+		expected map[string]string
+	}{
+		{
+			name:     "All participated",
+			s:        "5s 123 foo;bar",
+			expected: map[string]string{"duration": "5s", "num": "123", "str": "foo", "opt": "bar"},
+		},
+		{
+			name:     "Not participated",
+			s:        "5s 123",
+			expected: map[string]string{"duration": "5s", "num": "123"},
+		},
+		{
+			name:     "Participated empty",
+			s:        "5s 123 ;",
+			expected: map[string]string{"duration": "5s", "num": "123", "str": "", "opt": ""},
+		},
+		{
+			name:    "No match",
+			s:       "5s aa foo",
+			wantErr: &NoMatchFoundError{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups, err := r.MatchedGroups(tt.s)
+			if err != nil || tt.wantErr != nil {
+				isErrorMatch(t, tt.wantErr, err)
+				return
+			}
+
+			require.Equal(t, tt.expected, groups)
+		})
+	}
+}
+
 func cmpTime(t *testing.T, src time.Time, dst time.Time) {
 	t.Helper()
 	assert.Equal(t, strings.Replace(src.String(), " UTC", " +0000", 1), strings.Replace(dst.String(), " UTC", " +0000", 1), "Time not equal")
@@ -445,3 +518,549 @@ func TestTimeParsing(t *testing.T) {
 		})
 	}
 }
+
+func TestTimeOptions(t *testing.T) {
+	type Times struct {
+		Layouts  time.Time   `regroup:"t1,layout=RFC1123,layout=DateTime,layout='Jan 2, 2006'"`
+		Loc      time.Time   `regroup:"t2,layout=DateTime,loc=Europe/Berlin"`
+		Unix     time.Time   `regroup:"unix,unix"`
+		UnixMs   *time.Time  `regroup:"unixms,unixms"`
+		UnixNs   time.Time   `regroup:"unixns,unixns,loc=Asia/Tokyo"`
+		Required time.Time   `regroup:"t2,required,DateTime"`
+		Kitchen  []time.Time `regroup:"kitchen,split=|,layout=Kitchen"`
+	}
+	r := MustCompile(`^(?P<t1>[^/]*)/(?P<t2>[^/]*)/(?P<unix>[^/]*)/(?P<unixms>[^/]*)/(?P<unixns>[^/]*)/(?P<kitchen>[^/]*)$`)
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	t.Run("Layouts", func(t *testing.T) {
+		for _, t1 := range []string{"Mon, 04 Mar 2024 00:00:00 UTC", "2024-03-04 00:00:00", "Mar 4, 2024"} {
+			target := &Times{}
+			require.NoError(t, r.MatchToTarget(t1+"/2024-03-04 10:00:00/1700000000.5/1700000000123/1700000000000000001/3:04PM|11:00AM", target))
+			cmpTime(t, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), target.Layouts)
+			cmpTime(t, time.Date(2024, 3, 4, 10, 0, 0, 0, berlin), target.Loc)
+			assert.Equal(t, berlin, target.Loc.Location())
+			cmpTime(t, time.Unix(1700000000, 5e8), target.Unix)
+			assert.Equal(t, time.UTC, target.Unix.Location())
+			cmpTime(t, time.Unix(1700000000, 123e6), *target.UnixMs)
+			assert.True(t, time.Unix(1700000000, 1).Equal(target.UnixNs))
+			assert.Equal(t, tokyo, target.UnixNs.Location())
+			cmpTime(t, time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC), target.Required)
+			require.Len(t, target.Kitchen, 2)
+			cmpTime(t, time.Date(0, 1, 1, 15, 4, 0, 0, time.UTC), target.Kitchen[0])
+		}
+	})
+
+	t.Run("Empty groups", func(t *testing.T) {
+		target := &Times{}
+		isErrorMatch(t, &RequiredGroupIsEmpty{}, r.MatchToTarget("/////", target))
+		require.NoError(t, r.MatchToTarget("/2024-03-04 10:00:00////", target))
+		assert.True(t, target.Layouts.IsZero())
+	})
+
+	t.Run("Parse errors", func(t *testing.T) {
+		for _, s := range []string{
+			"2024/2024-03-04 10:00:00////",
+			"/2024-03-04 10:00:00/1.x///",
+			"/2024-03-04 10:00:00//1.5//",
+			"/2024-03-04 10:00:00///abc/",
+		} {
+			err := r.MatchToTarget(s, &Times{})
+			isErrorMatch(t, &ParseError{}, err)
+		}
+	})
+
+	t.Run("Local zone abbreviations", func(t *testing.T) {
+		la, err := time.LoadLocation("America/Los_Angeles")
+		require.NoError(t, err)
+		local := time.Local
+		time.Local = la
+		t.Cleanup(func() { time.Local = local })
+
+		type Zoned struct {
+			Time time.Time `regroup:"t,2006-01-02 15:04 MST"`
+		}
+		target := &Zoned{}
+		require.NoError(t, MustCompile(`(?P<t>.+)`).MatchToTarget("2024-01-02 10:00 PST", target))
+		_, offset := target.Time.Zone()
+		assert.Equal(t, -8*60*60, offset)
+	})
+
+	t.Run("Invalid options", func(t *testing.T) {
+		type Invalid struct {
+			Loc    time.Time `regroup:"t1,loc=Nowhere/Nothing"`
+			Both   time.Time `regroup:"t1,unix,layout=Kitchen"`
+			Epochs time.Time `regroup:"t1,unix,unixms"`
+			NoTime string    `regroup:"t1,layout=Kitchen"`
+		}
+		err := r.Validate(reflect.TypeOf(Invalid{}))
+		require.Error(t, err)
+		errs := err.(*ValidationError).Errors()
+		require.Len(t, errs, 4)
+		assert.IsType(t, &InvalidOptionValueError{}, errs[0])
+		assert.IsType(t, &InvalidOptionError{}, errs[1])
+		assert.IsType(t, &InvalidOptionError{}, errs[2])
+		assert.IsType(t, &InvalidOptionError{}, errs[3])
+	})
+}
+
+func TestCompositeTime(t *testing.T) {
+	type Entry struct {
+		Time     time.Time  `regroup:"date+time,layout='2006-01-02 15:04:05',layout=DateOnly"`
+		TimePtr  *time.Time `regroup:"date+time,layout='2006-01-02 15:04:05',layout=DateOnly"`
+		Syslog   time.Time  `regroup:"syslog,layout=Stamp,inferyear"`
+		Required time.Time  `regroup:"date+time,layout=DateTime,layout=DateOnly,required"`
+	}
+	clock := func() time.Time { return time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC) }
+	const pattern = `^(?P<date>[\d-]*)(?:T(?P<time>[\d:]+))?(?: (?P<syslog>.+))?$`
+	r := MustCompileFor[Entry](pattern, WithClock(clock))
+
+	got, err := r.Match("2024-03-04T10:11:12 Jan  1 23:00:00")
+	require.NoError(t, err)
+	expected := time.Date(2024, 3, 4, 10, 11, 12, 0, time.UTC)
+	cmpTime(t, expected, got.Time)
+	cmpTime(t, expected, *got.TimePtr)
+	cmpTime(t, time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC), got.Syslog)
+
+	got, err = r.Match("2024-03-04 Dec 31 23:00:00")
+	require.NoError(t, err)
+	cmpTime(t, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), got.Time)
+	cmpTime(t, time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC), got.Syslog)
+
+	// Feb 29 is set to the most recent leap year which isn't ahead of the clock
+	got, err = r.Match("2024-03-04 Feb 29 23:00:00")
+	require.NoError(t, err)
+	cmpTime(t, time.Date(2020, 2, 29, 23, 0, 0, 0, time.UTC), got.Syslog)
+	leapClock := func() time.Time { return time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC) }
+	leap := MustCompileFor[Entry](pattern, WithClock(leapClock))
+	got, err = leap.Match("2024-03-04 Feb 29 23:00:00")
+	require.NoError(t, err)
+	cmpTime(t, time.Date(2024, 2, 29, 23, 0, 0, 0, time.UTC), got.Syslog)
+
+	_, err = r.Match("")
+	isErrorMatch(t, &RequiredGroupIsEmpty{}, err)
+	_, err = r.Match("2024-03-04T99:00:00")
+	isErrorMatch(t, &ParseError{}, err)
+
+	type Invalid struct {
+		Missing  time.Time   `regroup:"date+missing"`
+		NotTime  string      `regroup:"date+time"`
+		Repeated []time.Time `regroup:"date+time"`
+		Epoch    time.Time   `regroup:"date,unix,inferyear"`
+	}
+	err = r.ReGroup().Validate(reflect.TypeOf(Invalid{}))
+	require.Error(t, err)
+	errs := err.(*ValidationError).Errors()
+	require.Len(t, errs, 4)
+	assert.IsType(t, &UnknownGroupError{}, errs[0])
+	assert.IsType(t, &InvalidOptionError{}, errs[1])
+	assert.IsType(t, &InvalidOptionError{}, errs[2])
+	assert.IsType(t, &InvalidOptionError{}, errs[3])
+}
+
+func TestDuplicateGroupNames(t *testing.T) {
+	type Timestamp struct {
+		Ts     string           `regroup:"ts"`
+		Exists bool             `regroup:"ts,exists"`
+		Raw    Captured[string] `regroup:"ts"`
+		Num    int              `regroup:"num"`
+	}
+	r := MustCompile(`(?:(?P<ts>\d+)|(?P<ts>[a-z]+ \d+)) (?P<num>\d+)`)
+
+	groups, err := r.Groups("123 5")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"ts": "123", "num": "5"}, groups)
+
+	groups, err = r.Groups("jan 2 5")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"ts": "jan 2", "num": "5"}, groups)
+
+	groups, err = r.MatchedGroups("jan 2 5")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"ts": "jan 2", "num": "5"}, groups)
+
+	target := &Timestamp{}
+	require.NoError(t, r.MatchToTarget("jan 2 5", target))
+	assert.Equal(t, &Timestamp{Ts: "jan 2", Exists: true, Raw: Captured[string]{Value: "jan 2", Raw: "jan 2", Start: 0, End: 5}, Num: 5}, target)
+
+	matches, err := r.MatchAllToTarget("123 5\njan 2 6", -1, &Timestamp{})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		&Timestamp{Ts: "123", Exists: true, Raw: Captured[string]{Value: "123", Raw: "123", Start: 0, End: 3}, Num: 5},
+		&Timestamp{Ts: "jan 2", Exists: true, Raw: Captured[string]{Value: "jan 2", Raw: "jan 2", Start: 6, End: 11}, Num: 6},
+	}, matches)
+
+	optional := MustCompile(`^(?P<ts>\d+)?(?:,(?P<ts>\w+))?$`)
+	groups, err = optional.Groups("")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"ts": ""}, groups)
+	groups, err = optional.MatchedGroups("")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{}, groups)
+}
+
+type color int
+
+func (c *color) UnmarshalRegroup(group string) error {
+	switch group {
+	case "red":
+		*c = 1
+	case "blue":
+		*c = 2
+	default:
+		return fmt.Errorf("unknown color %q", group)
+	}
+	return nil
+}
+
+// UnmarshalText shouldn't be used, as UnmarshalRegroup takes precedence
+func (c *color) UnmarshalText([]byte) error {
+	*c = -1
+	return nil
+}
+
+type csvFlag []string
+
+func (c *csvFlag) Set(s string) error {
+	*c = strings.Split(s, ",")
+	return nil
+}
+
+func TestUnmarshalers(t *testing.T) {
+	type Unmarshaled struct {
+		Addr    netip.Addr  `regroup:"addr"`
+		AddrPtr *netip.Addr `regroup:"addr"`
+		Color   color       `regroup:"color"`
+		Flag    csvFlag     `regroup:"flag"`
+	}
+	r := MustCompile(`(?P<addr>\S+) (?P<color>\S+) (?P<flag>\S+)`)
+
+	parsed := &Unmarshaled{AddrPtr: &netip.Addr{}}
+	require.NoError(t, r.MatchToTarget("10.0.0.1 blue a,b", parsed))
+	assert.Equal(t, &Unmarshaled{
+		Addr:    netip.MustParseAddr("10.0.0.1"),
+		AddrPtr: func() *netip.Addr { a := netip.MustParseAddr("10.0.0.1"); return &a }(),
+		Color:   2,
+		Flag:    csvFlag{"a", "b"},
+	}, parsed)
+
+	isErrorMatch(t, &ParseError{}, r.MatchToTarget("10.0.0.300 blue a,b", parsed))
+	isErrorMatch(t, &ParseError{}, r.MatchToTarget("10.0.0.1 green a,b", parsed))
+}
+
+func TestSubExpressions(t *testing.T) {
+	type Login struct {
+		User string `regroup:"user"`
+		Port int    `regroup:"port"`
+	}
+	type Request struct {
+		Method string `regroup:"method"`
+		Path   string `regroup:"path"`
+	}
+	type LogLine struct {
+		Level    string    `regroup:"level"`
+		Login    Login     `regroup:"msg,match=^login (?P<user>\\w+) port (?P<port>\\d+)$"`
+		Request  Request   `regroup:"msg,pattern=request"`
+		Requests []Request `regroup:"msg,pattern=request"`
+	}
+	request := MustCompile(`(?P<method>GET|POST) (?P<path>/[^\s,]*)`)
+	r := MustCompileFor[LogLine](`^(?P<level>\w+): (?P<msg>.*)$`, WithPattern("request", request))
+
+	got, err := r.Match("INFO: login bob port 22")
+	require.NoError(t, err)
+	assert.Equal(t, "INFO", got.Level)
+	assert.Equal(t, Login{User: "bob", Port: 22}, got.Login)
+
+	got, err = r.Match("DEBUG: GET /a, POST /b")
+	require.NoError(t, err)
+	assert.Equal(t, LogLine{
+		Level:    "DEBUG",
+		Request:  Request{Method: "GET", Path: "/a"},
+		Requests: []Request{{Method: "GET", Path: "/a"}, {Method: "POST", Path: "/b"}},
+	}, got)
+
+	loginPtr := &struct {
+		Login *Login `regroup:"msg,pattern=login"`
+	}{Login: &Login{}}
+	login := MustCompile(`^login (?P<user>\w+) port (?P<port>\d+)$`)
+	require.NoError(t, MustCompile(`^(?P<level>\w+): (?P<msg>.*)$`, WithPattern("login", login)).MatchToTarget("INFO: login bob port 22", loginPtr))
+	assert.Equal(t, &Login{User: "bob", Port: 22}, loginPtr.Login)
+
+	_, err = r.Match("INFO: login bob port 99999999999999999999")
+	isErrorMatch(t, &ParseError{}, err)
+}
+
+func TestSubExpressionsRequired(t *testing.T) {
+	type Inner struct {
+		Num int `regroup:"num"`
+	}
+	type Outer struct {
+		Inner Inner `regroup:"inner,match=^(?P<num>\\d+)$,required"`
+	}
+	r := MustCompile(`^(?P<inner>\w*)$`)
+
+	isErrorMatch(t, &RequiredGroupIsEmpty{}, r.MatchToTarget("", &Outer{}))
+	err := r.MatchToTarget("abc", &Outer{})
+	isErrorMatch(t, &ParseError{}, err)
+	assert.Contains(t, err.Error(), (&NoMatchFoundError{}).Error())
+}
+
+func TestSubExpressionsValidation(t *testing.T) {
+	type Inner struct {
+		Num int `regroup:"num"`
+	}
+	type Invalid struct {
+		Scalar   string  `regroup:"inner,match=\\d+"`
+		Repeated []Inner `regroup:"inner,match=(?P<num>\\d+)"`
+		Both     Inner   `regroup:"inner,match=(?P<num>\\d+),pattern=num"`
+		Each     Inner   `regroup:"inner,each=(?P<num>\\d+)"`
+		Unknown  Inner   `regroup:"inner,pattern=missing"`
+		BadGroup Inner   `regroup:"inner,match=(?P<other>\\d+)"`
+	}
+	r := MustCompile(`^(?P<inner>\w*)$`)
+	err := r.Validate(reflect.TypeOf(Invalid{}))
+	require.Error(t, err)
+	errs := err.(*ValidationError).Errors()
+	require.Len(t, errs, 6)
+	assert.IsType(t, &InvalidOptionError{}, errs[0])
+	assert.IsType(t, &InvalidOptionError{}, errs[1])
+	assert.IsType(t, &InvalidOptionError{}, errs[2])
+	assert.IsType(t, &InvalidOptionError{}, errs[3])
+	assert.IsType(t, &UnknownPatternError{}, errs[4])
+	assert.IsType(t, &UnknownGroupError{}, errs[5])
+}
+
+func TestMapFields(t *testing.T) {
+	type header string
+	type Request struct {
+		Method  string            `regroup:"method"`
+		Headers map[header]string `regroup:"hdr_*"`
+		Sizes   map[string]*int   `regroup:"size_*"`
+		Rest    map[string]string `regroup:"*"`
+		Nested  struct {
+			Path string `regroup:"path"`
+		}
+	}
+	r := MustCompileFor[Request](`^(?P<method>\w+) (?P<path>\S+)(?: host=(?P<hdr_host>\S*))?(?: agent=(?P<hdr_agent>\S*))?(?: (?P<size_body>\d+))? (?P<proto>\S+)(?P<extra>X)?$`)
+
+	intPtr := func(i int) *int { return &i }
+	got, err := r.Match("GET /a host=example.com agent= 12 HTTP/1.1")
+	require.NoError(t, err)
+	assert.Equal(t, "GET", got.Method)
+	assert.Equal(t, "/a", got.Nested.Path)
+	assert.Equal(t, map[header]string{"host": "example.com", "agent": ""}, got.Headers)
+	assert.Equal(t, map[string]*int{"body": intPtr(12)}, got.Sizes)
+	assert.Equal(t, map[string]string{"proto": "HTTP/1.1"}, got.Rest)
+
+	got, err = r.Match("GET /a HTTP/1.1")
+	require.NoError(t, err)
+	assert.Nil(t, got.Headers)
+	assert.Nil(t, got.Sizes)
+}
+
+func TestMapFieldsErrors(t *testing.T) {
+	type Required struct {
+		Headers map[string]string `regroup:"hdr_*,required"`
+	}
+	type Nums struct {
+		Nums map[string]int `regroup:"num_*"`
+	}
+	r := MustCompile(`^(?P<num_a>\w+)?(?:,(?P<hdr_b>\w+))?$`)
+
+	isErrorMatch(t, &RequiredGroupIsEmpty{}, r.MatchToTarget("1", &Required{}))
+	isErrorMatch(t, &ParseError{}, r.MatchToTarget("x", &Nums{}))
+
+	type Invalid struct {
+		NoWildcard map[string]string   `regroup:"num_a"`
+		IntKeys    map[int]string      `regroup:"num_*"`
+		Values     map[string]chan int `regroup:"num_*"`
+		Option     map[string]string   `regroup:"num_*,split=,"`
+		Unknown    map[string]string   `regroup:"foo_*"`
+	}
+	err := r.Validate(reflect.TypeOf(Invalid{}))
+	require.Error(t, err)
+	errs := err.(*ValidationError).Errors()
+	require.Len(t, errs, 5)
+	assert.IsType(t, &TypeNotParsableError{}, errs[0])
+	assert.IsType(t, &TypeNotParsableError{}, errs[1])
+	assert.IsType(t, &TypeNotParsableError{}, errs[2])
+	assert.IsType(t, &InvalidOptionError{}, errs[3])
+	assert.IsType(t, &UnknownGroupError{}, errs[4])
+}
+
+func TestNilPointerAllocation(t *testing.T) {
+	type Endpoint struct {
+		IP   string `regroup:"ip"`
+		Port *int   `regroup:"port"`
+	}
+	type Conn struct {
+		Name  *string            `regroup:"name"`
+		Peer  *Endpoint          `regroup:",prefix=peer_"`
+		Flags *[]string          `regroup:"flag"`
+		Extra *map[string]string `regroup:"x_*"`
+	}
+	r := MustCompile(`(?P<name>\w+)(?: (?P<peer_ip>[\d.]+)(?::(?P<peer_port>\d+))?)?(?: (?:(?P<flag>[a-z]+),)+)?(?: x=(?P<x_a>\w+))?;`)
+
+	strPtr := func(s string) *string { return &s }
+	intPtr := func(i int) *int { return &i }
+
+	target := &Conn{}
+	require.NoError(t, r.MatchToTarget("foo;", target))
+	assert.Equal(t, &Conn{Name: strPtr("foo")}, target)
+
+	target = &Conn{}
+	require.NoError(t, r.MatchToTarget("foo 10.0.0.1 a,b, x=y;", target))
+	assert.Equal(t, &Conn{Name: strPtr("foo"), Peer: &Endpoint{IP: "10.0.0.1"}, Flags: &[]string{"a", "b"}, Extra: &map[string]string{"a": "y"}}, target)
+
+	all, err := r.MatchAllToTarget("foo 10.0.0.1:80; bar;", -1, &Conn{Peer: &Endpoint{}})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		&Conn{Name: strPtr("foo"), Peer: &Endpoint{IP: "10.0.0.1", Port: intPtr(80)}},
+		&Conn{Name: strPtr("bar")},
+	}, all)
+
+	type Required struct {
+		Port *int `regroup:"peer_port,required"`
+	}
+	isErrorMatch(t, &RequiredGroupIsEmpty{}, r.MatchToTarget("foo;", &Required{}))
+}
+
+func TestNestedPrefix(t *testing.T) {
+	type Endpoint struct {
+		IP   string `regroup:"ip"`
+		Port int    `regroup:"port"`
+	}
+	type Hop struct {
+		Endpoint `regroup:",prefix=via_"`
+		TTL      int `regroup:"ttl"`
+	}
+	type Flow struct {
+		Src Endpoint  `regroup:",prefix=src_"`
+		Dst *Endpoint `regroup:",prefix=dst_"`
+		Hop Hop       `regroup:",prefix=hop_"`
+	}
+	r := MustCompile(`(?P<src_ip>\S+):(?P<src_port>\d+) -> (?P<dst_ip>\S+):(?P<dst_port>\d+) via (?P<hop_via_ip>\S+):(?P<hop_via_port>\d+) ttl=(?P<hop_ttl>\d+)`)
+
+	target := &Flow{Dst: &Endpoint{}}
+	require.NoError(t, r.MatchToTarget("10.0.0.1:1234 -> 10.0.0.2:80 via 10.0.0.254:53 ttl=3", target))
+	assert.Equal(t, &Flow{
+		Src: Endpoint{IP: "10.0.0.1", Port: 1234},
+		Dst: &Endpoint{IP: "10.0.0.2", Port: 80},
+		Hop: Hop{Endpoint: Endpoint{IP: "10.0.0.254", Port: 53}, TTL: 3},
+	}, target)
+
+	type Named struct {
+		Src struct {
+			IP string
+		} `regroup:",prefix=src_"`
+	}
+	named := &Named{}
+	require.NoError(t, MustCompile(`(?P<src_IP>\S+)`, WithNaming(ExactNaming)).MatchToTarget("10.0.0.1", named))
+	assert.Equal(t, "10.0.0.1", named.Src.IP)
+
+	type Invalid struct {
+		Src  Endpoint `regroup:",prefix=src_,required"`
+		Dst  Endpoint `regroup:",prefix=foo_"`
+		Port int      `regroup:"src_port,prefix=src_"`
+	}
+	err := r.Validate(reflect.TypeOf(Invalid{}))
+	require.Error(t, err)
+	errs := err.(*ValidationError).Errors()
+	require.Len(t, errs, 4)
+	assert.IsType(t, &InvalidOptionError{}, errs[0])
+	assert.IsType(t, &UnknownGroupError{}, errs[1])
+	assert.IsType(t, &UnknownGroupError{}, errs[2])
+	assert.IsType(t, &InvalidOptionError{}, errs[3])
+}
+
+type node struct {
+	Value int   `regroup:"value"`
+	Next  *node `regroup:",prefix=next_"`
+}
+
+func TestRecursiveType(t *testing.T) {
+	r := MustCompile(`(?P<value>\d+)(?:,(?P<next_value>\d+))?(?:,(?P<next_next_value>\d+))?`)
+
+	target := &node{}
+	require.NoError(t, r.MatchToTarget("1,2,3", target))
+	assert.Equal(t, &node{Value: 1, Next: &node{Value: 2, Next: &node{Value: 3}}}, target)
+
+	target = &node{}
+	require.NoError(t, r.MatchToTarget("1", target))
+	assert.Equal(t, &node{Value: 1}, target)
+
+	type noPrefix struct {
+		Value int `regroup:"value"`
+		Next  *noPrefix
+	}
+	err := r.Validate(reflect.TypeOf(noPrefix{}))
+	require.Error(t, err)
+	errs := err.(*ValidationError).Errors()
+	require.Len(t, errs, 1)
+	assert.IsType(t, &RecursiveTypeError{}, errs[0])
+}
+
+func TestPlanCache(t *testing.T) {
+	r := MustCompile(`(?P<duration>.*?)\s+(?P<num>\d+)\s+(?P<str>.*)`)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			target := &Including{}
+			assert.NoError(t, r.MatchToTarget(fmt.Sprintf("%ds %d foo", i, i), target))
+			assert.Equal(t, &Including{Single: Single{Duration: time.Duration(i) * time.Second}, Num: i, Str: "foo"}, target)
+		}(i)
+	}
+	wg.Wait()
+
+	plan, errs := r.plan(reflect.TypeOf(Including{}))
+	require.Empty(t, errs)
+	cached, errs := r.plan(reflect.TypeOf(Including{}))
+	require.Empty(t, errs)
+	assert.Same(t, plan, cached)
+
+	_, errs = r.plan(reflect.TypeOf(IncorrectGroup{}))
+	require.NotEmpty(t, errs)
+	_, ok := r.plans.Load(reflect.TypeOf(IncorrectGroup{}))
+	assert.False(t, ok, "Invalid plans shouldn't be cached")
+}
+
+type benchEntry struct {
+	Duration time.Duration `regroup:"duration"`
+	Num      int           `regroup:"num"`
+	Str      string        `regroup:"str,required"`
+	Ptr      *uint         `regroup:"num"`
+	Nested   struct {
+		Str string `regroup:"str"`
+	}
+}
+
+func BenchmarkMatchToTarget(b *testing.B) {
+	r := MustCompile(`(?P<duration>\w+)\s+(?P<num>\d+)\s+(?P<str>\w+)`)
+	target := &benchEntry{Ptr: uintPtr(0)}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := r.MatchToTarget("5s 123 foo", target); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMatchAllToTarget(b *testing.B) {
+	r := MustCompile(`(?P<duration>\w+)\s+(?P<num>\d+)\s+(?P<str>\w+)`)
+	s := strings.Repeat("5s 123 foo\n", 100)
+	target := &benchEntry{Ptr: uintPtr(0)}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.MatchAllToTarget(s, -1, target); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package regroup
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+)
+
+// expression is a compiled regex along with the indices of its named groups
+type expression struct {
+	matcher *regexp.Regexp
+	// groupIndices maps every group name to the indices of all the groups with this name
+	groupIndices map[string][]int
+	// repetitions maps the indices of named groups inside a repetition to the repetition they're in
+	repetitions map[int]*repetition
+}
+
+// repetition is a repeated sub-expression containing named groups.
+// Go reports only the last repetition of a group, so the span of the whole repetition is captured
+// and re-scanned with the repeated sub-expression to get the values of every repetition
+type repetition struct {
+	// spanIdx is the index of the unnamed group wrapping the whole repetition
+	spanIdx int
+	// body is the repeated sub-expression, anchored to the start of the text
+	body *expression
+}
+
+func newExpression(matcher *regexp.Regexp) *expression {
+	e := &expression{matcher: matcher, groupIndices: make(map[string][]int)}
+	for i, name := range matcher.SubexpNames() {
+		if i != 0 && name != "" {
+			e.groupIndices[name] = append(e.groupIndices[name], i)
+		}
+	}
+	return e
+}
+
+// isRepetition checks if the regex node can match more than once
+func isRepetition(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpStar, syntax.OpPlus:
+		return true
+	case syntax.OpRepeat:
+		return re.Max == -1 || re.Max > 1
+	}
+	return false
+}
+
+// hasNamedGroup checks if the regex node contains a named group
+func hasNamedGroup(re *syntax.Regexp) bool {
+	if re.Op == syntax.OpCapture && re.Name != "" {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if hasNamedGroup(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// wrapRepetitions wraps every outermost repetition containing a named group with an unnamed group,
+// and returns the repetitions bodies in the order of their wrapping groups
+func wrapRepetitions(re *syntax.Regexp) (bodies []*syntax.Regexp) {
+	for i, sub := range re.Sub {
+		if isRepetition(sub) && hasNamedGroup(sub) {
+			re.Sub[i] = &syntax.Regexp{Op: syntax.OpCapture, Sub: []*syntax.Regexp{sub}}
+			bodies = append(bodies, sub.Sub[0])
+			continue
+		}
+		bodies = append(bodies, wrapRepetitions(sub)...)
+	}
+	return bodies
+}
+
+// mapRepetitions walks the wrapped regex in the order of its groups indices (which is the order of the opening parentheses),
+// and maps every named group inside a repetition to the index of its wrapping group in groupSpans.
+// isBody identifies the wrapping groups by their repetition body, and their indices are returned in order
+func mapRepetitions(re *syntax.Regexp, isBody map[*syntax.Regexp]bool, spanIdx int, capIdx *int, groupSpans map[int]int) (spanIdxs []int) {
+	if re.Op == syntax.OpCapture {
+		*capIdx++
+		if re.Name != "" && spanIdx != 0 {
+			groupSpans[*capIdx] = spanIdx
+		}
+		if re.Name == "" && len(re.Sub[0].Sub) > 0 && isBody[re.Sub[0].Sub[0]] {
+			spanIdx = *capIdx
+			spanIdxs = append(spanIdxs, spanIdx)
+		}
+	}
+	for _, sub := range re.Sub {
+		spanIdxs = append(spanIdxs, mapRepetitions(sub, isBody, spanIdx, capIdx, groupSpans)...)
+	}
+	return spanIdxs
+}
+
+// compileRepetitions compiles the regex tree into an expression which can report every repetition of its named groups.
+// The tree is modified in place
+func compileRepetitions(re *syntax.Regexp) (*expression, error) {
+	// Wrap the root as well, in case the root itself is a repetition
+	root := &syntax.Regexp{Op: syntax.OpConcat, Sub: []*syntax.Regexp{re}}
+	bodies := wrapRepetitions(root)
+
+	matcher, err := regexp.Compile(root.String())
+	if err != nil {
+		return nil, err
+	}
+	e := newExpression(matcher)
+	if len(bodies) == 0 {
+		return e, nil
+	}
+
+	isBody := make(map[*syntax.Regexp]bool, len(bodies))
+	for _, body := range bodies {
+		isBody[body] = true
+	}
+	groupSpans := make(map[int]int)
+	capIdx := 0
+	// The wrapping groups are in the same order as the bodies
+	spanIdxs := mapRepetitions(root, isBody, 0, &capIdx, groupSpans)
+
+	repetitions := make(map[int]*repetition, len(spanIdxs))
+	for i, spanIdx := range spanIdxs {
+		// The matcher is already compiled, so the bodies can be modified by wrapping their own repetitions
+		anchored := &syntax.Regexp{Op: syntax.OpConcat, Sub: []*syntax.Regexp{{Op: syntax.OpBeginText}, bodies[i]}}
+		body, err := compileRepetitions(anchored)
+		if err != nil {
+			return nil, err
+		}
+		repetitions[spanIdx] = &repetition{spanIdx: spanIdx, body: body}
+	}
+
+	e.repetitions = make(map[int]*repetition, len(groupSpans))
+	for groupIdx, spanIdx := range groupSpans {
+		e.repetitions[groupIdx] = repetitions[spanIdx]
+	}
+	return e, nil
+}
+
+// groupValues returns the values of every repetition of the named group in the match.
+// If the group isn't repeated, its single value is returned. If the group didn't participate in the match, nil is returned.
+// An error is returned if the span of the repetitions can't be re-scanned into the same repetitions as the match,
+// as the repeated sub-expression may match differently on its own (such as with alternations or `\b`)
+func (e *expression) groupValues(s string, match []int, name string) ([]string, error) {
+	i := matchedGroup(match, e.groupIndices[name])
+	if !participated(match, i) {
+		return nil, nil
+	}
+
+	rep, ok := e.repetitions[i]
+	if !ok {
+		return []string{group(s, match, i)}, nil
+	}
+
+	var values []string
+	span := s[match[2*rep.spanIdx]:match[2*rep.spanIdx+1]]
+	rest := span
+	for len(rest) > 0 {
+		bodyMatch := rep.body.matcher.FindStringSubmatchIndex(rest)
+		if bodyMatch == nil || bodyMatch[1] == 0 {
+			break
+		}
+		bodyValues, err := rep.body.groupValues(rest, bodyMatch, name)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, bodyValues...)
+		rest = rest[bodyMatch[1]:]
+	}
+	if len(rest) > 0 || len(values) == 0 || values[len(values)-1] != group(s, match, i) {
+		return nil, fmt.Errorf("the repetitions of %q can't be matched separately", span)
+	}
+	return values, nil
+}
@@ -0,0 +1,308 @@
+package regroup
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepeatedGroups(t *testing.T) {
+	type Tags struct {
+		Name     string          `regroup:"name"`
+		Tags     []string        `regroup:"tag"`
+		Nums     []int           `regroup:"num"`
+		NumPtrs  []*int          `regroup:"num"`
+		Array    [3]uint8        `regroup:"num"`
+		Single   []string        `regroup:"name"`
+		Times    []time.Duration `regroup:"dur"`
+		Raw      []byte          `regroup:"name"`
+		LastTag  string          `regroup:"tag"`
+		Optional []string        `regroup:"missing"`
+	}
+	r := MustCompile(`^(?P<name>\w+):(?:(?P<tag>[a-z]+),?)*;(?:(?P<num>\d+)\s*){1,5}(?:/(?:(?P<dur>\w+)\|)+)?(?P<missing>X)?$`)
+
+	intPtr := func(i int) *int { return &i }
+	tests := []struct {
+		name     string
+		s        string
+		wantErr  error
+		expected *Tags
+	}{
+		{
+			name: "All repetitions",
+			s:    "foo:a,bb,ccc;1 2 3/5s|1m|",
+			expected: &Tags{
+				Name:    "foo",
+				Tags:    []string{"a", "bb", "ccc"},
+				Nums:    []int{1, 2, 3},
+				NumPtrs: []*int{intPtr(1), intPtr(2), intPtr(3)},
+				Array:   [3]uint8{1, 2, 3},
+				Single:  []string{"foo"},
+				Times:   []time.Duration{5 * time.Second, time.Minute},
+				Raw:     []byte("foo"),
+				LastTag: "ccc",
+			},
+		},
+		{
+			name: "No repetitions",
+			s:    "foo:;1",
+			expected: &Tags{
+				Name:    "foo",
+				Nums:    []int{1},
+				NumPtrs: []*int{intPtr(1)},
+				Array:   [3]uint8{1},
+				Single:  []string{"foo"},
+				Raw:     []byte("foo"),
+			},
+		},
+		{
+			name:    "Too many for array",
+			s:       "foo:a;1 2 3 4",
+			wantErr: &ParseError{},
+		},
+		{
+			name:    "Element parse error",
+			s:       "foo:a;1/5s|5ls|",
+			wantErr: &ParseError{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &Tags{}
+			err := r.MatchToTarget(tt.s, target)
+			if tt.wantErr != nil {
+				isErrorMatch(t, tt.wantErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, target)
+		})
+	}
+}
+
+func TestRepeatedGroupsElementError(t *testing.T) {
+	type Nums struct {
+		Nums []int `regroup:"num"`
+	}
+	r := MustCompile(`(?:(?P<num>\w+),?)+`)
+	err := r.MatchToTarget("1,2,x", &Nums{})
+	isErrorMatch(t, &ParseError{}, err)
+	assert.Contains(t, err.Error(), "element 2")
+
+	type Split struct {
+		Nums []int `regroup:"num,split=,"`
+	}
+	r = MustCompile(`^(?:(?P<num>[\w,]+);?)+$`)
+	err = r.MatchToTarget("80,,x", &Split{})
+	isErrorMatch(t, &ParseError{}, err)
+	assert.Contains(t, err.Error(), "element 2")
+
+	err = r.MatchToTarget("1,,2;3,x", &Split{})
+	isErrorMatch(t, &ParseError{}, err)
+	assert.Contains(t, err.Error(), "element 4")
+}
+
+func TestRepeatedGroupsAmbiguousBody(t *testing.T) {
+	type Values struct {
+		X []string `regroup:"x"`
+	}
+	tests := []struct {
+		name    string
+		pattern string
+		s       string
+	}{
+		{
+			// The body alone matches `a` first, while the whole expression matched `ab`
+			name:    "Alternation",
+			pattern: `^(?:(?P<x>a|ab))*c$`,
+			s:       "abc",
+		},
+		{
+			// The body alone loses the text before the repetitions
+			name:    "Word boundary",
+			pattern: `^x(?:\B(?P<x>\w))+$`,
+			s:       "xabc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := MustCompile(tt.pattern)
+			isErrorMatch(t, &ParseError{}, r.MatchToTarget(tt.s, &Values{}))
+		})
+	}
+}
+
+func TestNestedRepetitions(t *testing.T) {
+	type Matrix struct {
+		Cells []int    `regroup:"cell"`
+		Rows  []string `regroup:"row"`
+	}
+	r := MustCompileFor[Matrix](`^(?:(?P<row>(?:(?P<cell>\d+),)+);)+$`)
+
+	got, err := r.Match("1,2,;3,;4,5,6,;")
+	require.NoError(t, err)
+	assert.Equal(t, Matrix{Cells: []int{1, 2, 3, 4, 5, 6}, Rows: []string{"1,2,", "3,", "4,5,6,"}}, got)
+
+	all, err := r.MatchAll("1,2,;3,;", -1)
+	require.NoError(t, err)
+	assert.Equal(t, []Matrix{{Cells: []int{1, 2, 3}, Rows: []string{"1,2,", "3,"}}}, all)
+}
+
+func TestRepeatedGroupsPlanExpression(t *testing.T) {
+	type Skipped struct {
+		Name    string `regroup:"name"`
+		Raw     []string
+		Ignored []int `regroup:"-"`
+	}
+	type Repeated struct {
+		Tags []string `regroup:"tag"`
+	}
+	r := MustCompile(`^(?P<name>\w+):(?:(?P<tag>[a-z]+),?)*$`)
+
+	plan, errs := r.plan(reflect.TypeOf(Skipped{}))
+	require.Empty(t, errs)
+	assert.Same(t, r.expression, plan.expr)
+
+	plan, errs = r.plan(reflect.TypeOf(Repeated{}))
+	require.Empty(t, errs)
+	assert.NotSame(t, r.expression, plan.expr)
+}
+
+func TestRepeatedGroupsArrayReuse(t *testing.T) {
+	type Nums struct {
+		Array [3]int `regroup:"num"`
+	}
+	r := MustCompile(`^(?:(?P<num>\d+),?)+$`)
+
+	target := &Nums{}
+	require.NoError(t, r.MatchToTarget("1,2,3", target))
+	assert.Equal(t, [3]int{1, 2, 3}, target.Array)
+	require.NoError(t, r.MatchToTarget("4", target))
+	assert.Equal(t, [3]int{4}, target.Array)
+}
+
+func TestRepeatedGroupsRequired(t *testing.T) {
+	type Tags struct {
+		Tags []string `regroup:"tag,required"`
+	}
+	r := MustCompile(`^(?:(?P<tag>[a-z]+),?)*$`)
+	isErrorMatch(t, &RequiredGroupIsEmpty{}, r.MatchToTarget("", &Tags{}))
+
+	target := &Tags{}
+	require.NoError(t, r.MatchToTarget("a,b", target))
+	assert.Equal(t, []string{"a", "b"}, target.Tags)
+}
+
+func TestRepeatedGroupsNotParsable(t *testing.T) {
+	type Chans struct {
+		Chans []chan int `regroup:"tag"`
+	}
+	r := MustCompile(`^(?:(?P<tag>[a-z]+),?)*$`)
+	isErrorMatch(t, &ValidationError{}, r.Bind(&Chans{}))
+}
+
+func TestRepeatedGroupsWithUnnamedGroups(t *testing.T) {
+	type Mixed struct {
+		Nums []int  `regroup:"num"`
+		Last string `regroup:"last"`
+		Num  int    `regroup:"num"`
+	}
+	r := MustCompile(`^(a|b)(?:(?P<num>\d)(,))*(c)?(?P<last>\w+)$`)
+
+	target := &Mixed{}
+	require.NoError(t, r.MatchToTarget("a1,2,3,cfoo", target))
+	assert.Equal(t, &Mixed{Nums: []int{1, 2, 3}, Last: "foo", Num: 3}, target)
+
+	groups, err := r.Groups("a1,2,3,cfoo")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"num": "3", "last": "foo"}, groups)
+}
+
+func TestSplitOption(t *testing.T) {
+	type Ports struct {
+		Ports    []uint16    `regroup:"ports,split=,"`
+		PortPtrs []*uint16   `regroup:"ports,split=,,required"`
+		Pairs    []string    `regroup:"pairs,split= "`
+		Array    [2]string   `regroup:"pairs,split= "`
+		Repeated []int       `regroup:"rep,split=|"`
+		Times    []time.Time `regroup:"times,split=;,2006-01-02"`
+	}
+	r := MustCompile(`^(?P<ports>[\w, ]*)/(?P<pairs>[^/]*)/(?:(?P<rep>[\d|]+):)*/(?P<times>.*)$`)
+
+	portPtr := func(p uint16) *uint16 { return &p }
+	target := &Ports{}
+	require.NoError(t, r.MatchToTarget("80, 443,,8080/k1=v1 k2=v2/1|2:3:/2024-01-02;2024-03-04", target))
+	assert.Equal(t, &Ports{
+		Ports:    []uint16{80, 443, 8080},
+		PortPtrs: []*uint16{portPtr(80), portPtr(443), portPtr(8080)},
+		Pairs:    []string{"k1=v1", "k2=v2"},
+		Array:    [2]string{"k1=v1", "k2=v2"},
+		Repeated: []int{1, 2, 3},
+		Times:    []time.Time{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+	}, target)
+
+	err := r.MatchToTarget("80,4x3/k1=v1///", &Ports{})
+	isErrorMatch(t, &ParseError{}, err)
+	assert.Contains(t, err.Error(), "element 1")
+
+	isErrorMatch(t, &RequiredGroupIsEmpty{}, r.MatchToTarget(" , /k1=v1///", &Ports{}))
+	isErrorMatch(t, &ParseError{}, r.MatchToTarget("80/a b c///", &Ports{}))
+}
+
+func TestEachOption(t *testing.T) {
+	type Item struct {
+		Key   string `regroup:"key"`
+		Value int    `regroup:"value"`
+	}
+	type Items struct {
+		Items    []Item   `regroup:"items,each=(?P<key>\\w+)=(?P<value>\\d+)"`
+		ItemPtrs []*Item  `regroup:"items,each=(?P<key>\\w+)=(?P<value>\\d+)"`
+		Values   []int    `regroup:"items,each=\\d+"`
+		Keys     []string `regroup:"items,each=[a-z]+"`
+	}
+	r := MustCompileFor[Items](`^items: (?P<items>.*)$`)
+
+	got, err := r.Match("items: a=1 b=2, c=3")
+	require.NoError(t, err)
+	assert.Equal(t, Items{
+		Items:    []Item{{Key: "a", Value: 1}, {Key: "b", Value: 2}, {Key: "c", Value: 3}},
+		ItemPtrs: []*Item{{Key: "a", Value: 1}, {Key: "b", Value: 2}, {Key: "c", Value: 3}},
+		Values:   []int{1, 2, 3},
+		Keys:     []string{"a", "b", "c"},
+	}, got)
+
+	got, err = r.Match("items: none")
+	require.NoError(t, err)
+	assert.Equal(t, Items{Keys: []string{"none"}}, got)
+
+	_, err = r.Match("items: a=1 b=99999999999999999999")
+	isErrorMatch(t, &ParseError{}, err)
+	assert.Contains(t, err.Error(), "element 1")
+}
+
+func TestSplitEachOptionsValidation(t *testing.T) {
+	type Invalid struct {
+		NotSlice  string   `regroup:"items,split=,"`
+		Both      []string `regroup:"items,split=|,each=\\w+"`
+		BadRegex  []string `regroup:"items,each=\\w+("`
+		BadStruct []struct {
+			Missing string `regroup:"missing"`
+		} `regroup:"items,each=\\w+"`
+		NoEach []struct {
+			Key string `regroup:"key"`
+		} `regroup:"items"`
+	}
+	r := MustCompile(`^items: (?P<items>.*)$`)
+	err := r.Validate(reflect.TypeOf(Invalid{}))
+	require.Error(t, err)
+	errs := err.(*ValidationError).Errors()
+	require.Len(t, errs, 5)
+	assert.IsType(t, &InvalidOptionError{}, errs[0])
+	assert.IsType(t, &InvalidOptionError{}, errs[1])
+	assert.IsType(t, &CompileError{}, errs[2])
+	assert.IsType(t, &UnknownGroupError{}, errs[3])
+	assert.IsType(t, &TypeNotParsableError{}, errs[4])
+}
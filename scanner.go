@@ -0,0 +1,181 @@
+package regroup
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"regexp"
+)
+
+// DefaultMaxRecordSize is the maximum size of a single record, unless set otherwise with Scanner.MaxRecordSize
+const DefaultMaxRecordSize = bufio.MaxScanTokenSize
+
+// Scanner reads line-oriented input and parses every matching record into a new target, similar to bufio.Scanner.
+// Successive calls to Scan will step through the matching records, skipping the unmatched ones.
+// By default every line is a record, use RecordStart or RecordContinuation to group several lines into a single record
+type Scanner struct {
+	scanner   *bufio.Scanner
+	re        *ReGroup
+	targetRef reflect.Value
+	plan      *structPlan
+	unmatched func(record string)
+	started   bool
+
+	recordStart        *regexp.Regexp
+	recordContinuation *regexp.Regexp
+	maxRecordSize      int
+	// pending is a line which was read ahead and starts the next record
+	pending    []byte
+	hasPending bool
+
+	value interface{}
+	text  string
+	err   error
+}
+
+// NewScanner returns a new Scanner reading from r and matching every record with re.
+// Every matching record is parsed into a new object of the same type as `target` argument, which must be a struct pointer
+func NewScanner(r io.Reader, re *ReGroup, target interface{}) *Scanner {
+	s := &Scanner{scanner: bufio.NewScanner(r), re: re, maxRecordSize: DefaultMaxRecordSize}
+	s.targetRef, s.err = re.validateTarget(target)
+	if s.err == nil {
+		s.plan, s.err = re.targetPlan(s.targetRef)
+	}
+	return s
+}
+
+// MaxRecordSize sets the maximum size of a single record. Scan will fail with bufio.ErrTooLong on longer records.
+// It panics if it is called after scanning has started
+func (s *Scanner) MaxRecordSize(size int) {
+	if s.started {
+		panic("regroup: MaxRecordSize called after Scan")
+	}
+	s.maxRecordSize = size
+	s.scanner.Buffer(nil, size)
+}
+
+// RecordStart sets a pattern matching the first line of every record.
+// Lines which don't match it are appended to the current record, separated by a new line,
+// so the ReGroup expression should use the `s` flag to match across them.
+// It panics if it is called after scanning has started
+func (s *Scanner) RecordStart(re *regexp.Regexp) {
+	if s.started {
+		panic("regroup: RecordStart called after Scan")
+	}
+	s.recordStart = re
+}
+
+// RecordContinuation sets a pattern matching lines which continue the current record, such as indented stack frames.
+// Lines which match it are appended to the current record, separated by a new line,
+// so the ReGroup expression should use the `s` flag to match across them.
+// It panics if it is called after scanning has started
+func (s *Scanner) RecordContinuation(re *regexp.Regexp) {
+	if s.started {
+		panic("regroup: RecordContinuation called after Scan")
+	}
+	s.recordContinuation = re
+}
+
+// continues checks if given line is a continuation of the current record
+func (s *Scanner) continues(line []byte) bool {
+	if s.recordContinuation != nil && s.recordContinuation.Match(line) {
+		return true
+	}
+	return s.recordStart != nil && !s.recordStart.Match(line)
+}
+
+// nextRecord reads the next logical record, assembling continuation lines into it
+func (s *Scanner) nextRecord() (string, bool) {
+	if s.recordStart == nil && s.recordContinuation == nil {
+		if !s.scanner.Scan() {
+			return "", false
+		}
+		return s.scanner.Text(), true
+	}
+
+	var record []byte
+	if s.hasPending {
+		record, s.hasPending = s.pending, false
+	} else {
+		if !s.scanner.Scan() {
+			return "", false
+		}
+		record = append(record, s.scanner.Bytes()...)
+	}
+
+	for s.scanner.Scan() {
+		line := s.scanner.Bytes()
+		if !s.continues(line) {
+			s.pending, s.hasPending = append(s.pending[:0:0], line...), true
+			break
+		}
+		if len(record)+1+len(line) > s.maxRecordSize {
+			s.err = bufio.ErrTooLong
+			return "", false
+		}
+		record = append(append(record, '\n'), line...)
+	}
+	if s.err = s.scanner.Err(); s.err != nil {
+		// Don't return a partial record
+		return "", false
+	}
+	return string(record), true
+}
+
+// OnUnmatched sets a function to be called with every record that doesn't match the regex
+func (s *Scanner) OnUnmatched(fn func(record string)) {
+	s.unmatched = fn
+}
+
+// Scan advances the Scanner to the next matching record, which will then be available through Value.
+// It returns false when the scan stops, either by reaching the end of the input or an error.
+// After Scan returns false, Err will return any error that occurred during scanning, except io.EOF
+func (s *Scanner) Scan() bool {
+	s.started = true
+	s.value, s.text = nil, ""
+	if s.err != nil {
+		return false
+	}
+
+	for {
+		record, ok := s.nextRecord()
+		if !ok {
+			break
+		}
+		match := s.plan.expr.matcher.FindStringSubmatchIndex(record)
+		if match == nil {
+			if s.unmatched != nil {
+				s.unmatched(record)
+			}
+			continue
+		}
+
+		target := reflect.New(s.targetRef.Type()).Elem()
+		if s.err = s.plan.fill(record, match, target); s.err != nil {
+			return false
+		}
+		s.value, s.text = target.Addr().Interface(), record
+		return true
+	}
+
+	if s.err == nil {
+		s.err = s.scanner.Err()
+	}
+	return false
+}
+
+// Value returns the target parsed from the most recent record matched by Scan.
+// It's the same type as the `target` argument given to NewScanner
+func (s *Scanner) Value() interface{} {
+	return s.value
+}
+
+// Text returns the most recent record matched by Scan
+func (s *Scanner) Text() string {
+	return s.text
+}
+
+// Err returns the first non-EOF error that was encountered by the Scanner
+func (s *Scanner) Err() error {
+	return s.err
+}
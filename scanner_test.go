@@ -0,0 +1,168 @@
+package regroup
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner(t *testing.T) {
+	r := MustCompile(`^(?P<duration>\S+)\s+(?P<num>\d+)\s+(?P<str>.*)$`)
+
+	tests := []struct {
+		name          string
+		input         string
+		target        interface{}
+		maxRecordSize int
+		wantErr       error
+		expected      []interface{}
+		unmatched     []string
+	}{
+		{
+			name:     "All matching",
+			input:    "5s 123 foo\n8h 456 bar\n",
+			target:   &Including{},
+			expected: []interface{}{&Including{Single: Single{Duration: 5 * time.Second}, Num: 123, Str: "foo"}, &Including{Single: Single{Duration: 8 * time.Hour}, Num: 456, Str: "bar"}},
+		},
+		{
+			name:      "Unmatched lines",
+			input:     "header\r\n5s 123 foo\r\n\r\n8h 456 bar",
+			target:    &Single{},
+			expected:  []interface{}{&Single{Duration: 5 * time.Second}, &Single{Duration: 8 * time.Hour}},
+			unmatched: []string{"header", ""},
+		},
+		{
+			name:     "Empty input",
+			input:    "",
+			target:   &Single{},
+			expected: nil,
+		},
+		{
+			name:     "Parse error",
+			input:    "5s 123 foo\n5ls 123 foo\n8h 456 bar",
+			target:   &Single{},
+			wantErr:  &ParseError{},
+			expected: []interface{}{&Single{Duration: 5 * time.Second}},
+		},
+		{
+			name:    "No struct pointer",
+			input:   "5s 123 foo",
+			target:  Single{},
+			wantErr: &NotStructPtrError{},
+		},
+		{
+			name:    "Invalid target",
+			input:   "5s 123 foo",
+			target:  &IncorrectGroup{},
+			wantErr: &UnknownGroupError{},
+		},
+		{
+			name:          "Record too long",
+			input:         "5s 123 foo\n5s 123 " + strings.Repeat("foo", 10),
+			target:        &Single{},
+			maxRecordSize: 20,
+			wantErr:       bufio.ErrTooLong,
+			expected:      []interface{}{&Single{Duration: 5 * time.Second}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(strings.NewReader(tt.input), r, tt.target)
+			if tt.maxRecordSize != 0 {
+				scanner.MaxRecordSize(tt.maxRecordSize)
+			}
+			var unmatched []string
+			scanner.OnUnmatched(func(record string) {
+				unmatched = append(unmatched, record)
+			})
+
+			var got []interface{}
+			for scanner.Scan() {
+				got = append(got, scanner.Value())
+				assert.NotEmpty(t, scanner.Text())
+			}
+			assert.Nil(t, scanner.Value())
+
+			if tt.wantErr != nil {
+				isErrorMatch(t, tt.wantErr, scanner.Err())
+			} else {
+				require.NoError(t, scanner.Err())
+			}
+			assert.Equal(t, tt.expected, got)
+			assert.Equal(t, tt.unmatched, unmatched)
+		})
+	}
+}
+
+func TestScannerMaxRecordSizeAfterScan(t *testing.T) {
+	scanner := NewScanner(strings.NewReader("5s 123 foo"), MustCompile(`(?P<duration>\S+)`), &Single{})
+	scanner.Scan()
+	assert.Panics(t, func() { scanner.MaxRecordSize(10) })
+}
+
+func TestScannerMultiLine(t *testing.T) {
+	type Panic struct {
+		Level string `regroup:"level"`
+		Msg   string `regroup:"msg"`
+		Stack string `regroup:"stack"`
+	}
+	r := MustCompile(`(?s)^(?P<level>[A-Z]+) (?P<msg>[^\n]*)\n?(?P<stack>.*)$`)
+	input := "garbage\nERROR boom\ngoroutine 1 [running]:\n\tmain.go:10\nINFO ok\nWARN slow\n\tfoo.go:1"
+
+	expected := []interface{}{
+		&Panic{Level: "ERROR", Msg: "boom", Stack: "goroutine 1 [running]:\n\tmain.go:10"},
+		&Panic{Level: "INFO", Msg: "ok"},
+		&Panic{Level: "WARN", Msg: "slow", Stack: "\tfoo.go:1"},
+	}
+
+	t.Run("Record start", func(t *testing.T) {
+		scanner := NewScanner(strings.NewReader(input), r, &Panic{})
+		scanner.RecordStart(regexp.MustCompile(`^[A-Z]+ `))
+		var unmatched []string
+		scanner.OnUnmatched(func(record string) {
+			unmatched = append(unmatched, record)
+		})
+		var got []interface{}
+		for scanner.Scan() {
+			got = append(got, scanner.Value())
+		}
+		require.NoError(t, scanner.Err())
+		assert.Equal(t, expected, got)
+		assert.Equal(t, []string{"garbage"}, unmatched)
+	})
+
+	t.Run("Record continuation", func(t *testing.T) {
+		scanner := NewScanner(strings.NewReader(input), r, &Panic{})
+		scanner.RecordContinuation(regexp.MustCompile(`^(\t|goroutine )`))
+		var got []interface{}
+		for scanner.Scan() {
+			got = append(got, scanner.Value())
+		}
+		require.NoError(t, scanner.Err())
+		assert.Equal(t, expected, got)
+	})
+
+	t.Run("Record too long", func(t *testing.T) {
+		scanner := NewScanner(strings.NewReader(input), r, &Panic{})
+		scanner.RecordStart(regexp.MustCompile(`^[A-Z]+ `))
+		scanner.MaxRecordSize(20)
+		var got []interface{}
+		for scanner.Scan() {
+			got = append(got, scanner.Value())
+		}
+		isErrorMatch(t, bufio.ErrTooLong, scanner.Err())
+		assert.Empty(t, got)
+	})
+
+	t.Run("Set after scan", func(t *testing.T) {
+		scanner := NewScanner(strings.NewReader(input), r, &Panic{})
+		scanner.Scan()
+		assert.Panics(t, func() { scanner.RecordStart(regexp.MustCompile(`^[A-Z]+ `)) })
+		assert.Panics(t, func() { scanner.RecordContinuation(regexp.MustCompile(`^\t`)) })
+	})
+}
@@ -0,0 +1,154 @@
+package regroup
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// tagOption is a single option of a struct tag, either `key` or `key=value`
+type tagOption struct {
+	key      string
+	value    string
+	hasValue bool
+}
+
+func (o tagOption) String() string {
+	if o.hasValue {
+		return o.key + "=" + o.value
+	}
+	return o.key
+}
+
+// fieldTag is the parsed regroup struct tag of a field, `group[,option]...`
+type fieldTag struct {
+	group   string
+	options []tagOption
+}
+
+// has checks if the tag has an option with given key
+func (t *fieldTag) has(key string) bool {
+	for _, option := range t.options {
+		if option.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// value returns the value of the first `key=value` option with given key
+func (t *fieldTag) value(key string) (string, bool) {
+	for _, option := range t.options {
+		if option.key == key && option.hasValue {
+			return option.value, true
+		}
+	}
+	return "", false
+}
+
+// tagToken accumulates a single comma separated part of the tag
+type tagToken struct {
+	key, value strings.Builder
+	hasValue   bool
+	// literal is the length of the key up to its last quoted or escaped character, which isn't trimmed
+	literal int
+}
+
+func (t *tagToken) current() *strings.Builder {
+	if t.hasValue {
+		return &t.value
+	}
+	return &t.key
+}
+
+func (t *tagToken) writeLiteral(s string) {
+	t.current().WriteString(s)
+	if !t.hasValue {
+		t.literal = t.key.Len()
+	}
+}
+
+func (t *tagToken) option() tagOption {
+	key := t.key.String()
+	if !t.hasValue {
+		// Trailing whitespace is trimmed, unless it was quoted or escaped
+		key = key[:t.literal] + strings.TrimRightFunc(key[t.literal:], unicode.IsSpace)
+	} else {
+		key = strings.TrimRightFunc(key, unicode.IsSpace)
+	}
+	// Values are kept as is, as they may be whitespace (such as `split= `)
+	return tagOption{key: key, value: t.value.String(), hasValue: t.hasValue}
+}
+
+// parseTag parses a regroup struct tag.
+// The first part of the tag is the group name, followed by options which are either `key` or `key=value`, separated by ','.
+// Text inside single quotes is taken as is, and `\,` and `\'` escape a comma and a quote outside of quotes.
+// A ',' right after the '=' of an option is its value (such as `split=,`)
+func parseTag(tag string) (fieldTag, error) {
+	var parsed fieldTag
+	token := &tagToken{}
+	first := true
+	quoted := false
+
+	endToken := func() {
+		option := token.option()
+		if first {
+			parsed.group = strings.TrimSpace(option.String())
+			first = false
+		} else {
+			parsed.options = append(parsed.options, option)
+		}
+		token = &tagToken{}
+	}
+
+	for i := 0; i < len(tag); i++ {
+		c := tag[i]
+		current := token.current()
+		switch {
+		case quoted && c == '\\' && i+1 < len(tag) && tag[i+1] == '\'':
+			token.writeLiteral("'")
+			i++
+		case quoted && c == '\'':
+			quoted = false
+			if !token.hasValue {
+				token.literal = current.Len()
+			}
+		case quoted:
+			token.writeLiteral(tag[i : i+1])
+		case c == '\'':
+			quoted = true
+		case c == '\\' && i+1 < len(tag) && (tag[i+1] == ',' || tag[i+1] == '\''):
+			token.writeLiteral(tag[i+1 : i+2])
+			i++
+		case c == ',' && token.hasValue && token.value.Len() == 0 && tag[i-1] == '=':
+			token.writeLiteral(",")
+		case c == ',':
+			endToken()
+		case c == '=' && !first && !token.hasValue:
+			token.hasValue = true
+		case unicode.IsSpace(rune(c)) && !token.hasValue && current.Len() == 0:
+			// Leading whitespace of keys is trimmed
+		default:
+			current.WriteByte(c)
+		}
+	}
+	if quoted {
+		return fieldTag{}, errors.New("unterminated quote")
+	}
+	endToken()
+	return parsed, nil
+}
+
+// fieldTag parses the regroup struct tag of the field
+func (r *ReGroup) fieldTag(fieldType reflect.StructField) (fieldTag, error) {
+	tag := fieldType.Tag.Get("regroup")
+	if tag == "" {
+		return fieldTag{}, nil
+	}
+	parsed, err := parseTag(tag)
+	if err != nil {
+		return fieldTag{}, &InvalidTagError{tag: tag, fieldName: fieldType.Name, err: err}
+	}
+	return parsed, nil
+}
@@ -0,0 +1,126 @@
+package regroup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      string
+		wantErr  bool
+		expected fieldTag
+	}{
+		{
+			name:     "Group only",
+			tag:      " name ",
+			expected: fieldTag{group: "name"},
+		},
+		{
+			name: "Options",
+			tag:  "name, required ,each=\\w+",
+			expected: fieldTag{group: "name", options: []tagOption{
+				{key: "required"},
+				{key: "each", value: "\\w+", hasValue: true},
+			}},
+		},
+		{
+			name: "Comma value",
+			tag:  "name,split=,,required",
+			expected: fieldTag{group: "name", options: []tagOption{
+				{key: "split", value: ",", hasValue: true},
+				{key: "required"},
+			}},
+		},
+		{
+			name: "Value ending with equal sign",
+			tag:  "name,each=(?P<k>\\w+)=,required",
+			expected: fieldTag{group: "name", options: []tagOption{
+				{key: "each", value: "(?P<k>\\w+)=", hasValue: true},
+				{key: "required"},
+			}},
+		},
+		{
+			name: "Whitespace value",
+			tag:  "name,split= ",
+			expected: fieldTag{group: "name", options: []tagOption{
+				{key: "split", value: " ", hasValue: true},
+			}},
+		},
+		{
+			name: "Quoted",
+			tag:  "name,'Jan 2, 2006 15:04',match='a,b=c\\'d',required",
+			expected: fieldTag{group: "name", options: []tagOption{
+				{key: "Jan 2, 2006 15:04"},
+				{key: "match", value: "a,b=c'd", hasValue: true},
+				{key: "required"},
+			}},
+		},
+		{
+			name: "Escaped",
+			tag:  "name,Jan 2\\, 2006,split=\\,,each=\\d\\,\\'",
+			expected: fieldTag{group: "name", options: []tagOption{
+				{key: "Jan 2, 2006"},
+				{key: "split", value: ",", hasValue: true},
+				{key: "each", value: "\\d,'", hasValue: true},
+			}},
+		},
+		{
+			name: "Quoted whitespace",
+			tag:  "name,' '",
+			expected: fieldTag{group: "name", options: []tagOption{
+				{key: " "},
+			}},
+		},
+		{
+			name:     "Prefix only",
+			tag:      ",prefix=src_",
+			expected: fieldTag{options: []tagOption{{key: "prefix", value: "src_", hasValue: true}}},
+		},
+		{
+			name:    "Unterminated quote",
+			tag:     "name,match='abc",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTag(tt.tag)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestTagErrors(t *testing.T) {
+	type Fields struct {
+		Quote   string    `regroup:"date,match='abc"`
+		Unknown string    `regroup:"date,foo=bar"`
+		Date    time.Time `regroup:"date,'Jan 2, 2006'"`
+	}
+	r := MustCompile(`(?P<date>.*)`)
+	err := r.Bind(&Fields{})
+	require.Error(t, err)
+	errs := err.(*ValidationError).Errors()
+	require.Len(t, errs, 2)
+	assert.IsType(t, &InvalidTagError{}, errs[0])
+	assert.IsType(t, &UnknownOptionError{}, errs[1])
+	assert.Contains(t, errs[1].Error(), "foo=bar")
+
+	type Date struct {
+		Date    time.Time `regroup:"date,'Jan 2, 2006'"`
+		Escaped time.Time `regroup:"date,Jan 2\\, 2006"`
+	}
+	target := &Date{}
+	require.NoError(t, r.MatchToTarget("Mar 4, 2024", target))
+	expected := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, &Date{Date: expected, Escaped: expected}, target)
+}
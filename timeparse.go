@@ -0,0 +1,208 @@
+package regroup
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	layoutOption = "layout"
+	locOption    = "loc"
+	unixOption   = "unix"
+	unixMsOption = "unixms"
+	unixNsOption = "unixns"
+	// inferYearOption sets the year of values without a year by the reference clock, see WithClock
+	inferYearOption = "inferyear"
+)
+
+// namedLayouts are the layouts which can be given by name instead of the layout itself
+var namedLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"StampMilli":  time.StampMilli,
+	"StampMicro":  time.StampMicro,
+	"StampNano":   time.StampNano,
+	"DateTime":    "2006-01-02 15:04:05",
+	"DateOnly":    "2006-01-02",
+	"TimeOnly":    "15:04:05",
+}
+
+// epochUnits are the options parsing unix epochs, mapped to the duration of a single unit
+var epochUnits = map[string]time.Duration{
+	unixOption:   time.Second,
+	unixMsOption: time.Millisecond,
+	unixNsOption: time.Nanosecond,
+}
+
+// timeFormat is the precompiled instructions for parsing time.Time values
+type timeFormat struct {
+	// layouts are tried in order, until one of them parses the value
+	layouts []string
+	// unit is set for unix epochs, as the duration of a single unit
+	unit time.Duration
+	// loc is the location of values without a time zone, and of unix epochs.
+	// If not set, values are parsed by time.Parse (zone abbreviations are resolved against the local zone) and epochs are in UTC
+	loc *time.Location
+	// clock is set if the year of values without a year is inferred
+	clock func() time.Time
+}
+
+// WithClock is a compile option setting the reference clock for the `inferyear` option of time fields, time.Now by default
+func WithClock(clock func() time.Time) Option {
+	return func(r *ReGroup) {
+		r.clock = clock
+	}
+}
+
+// buildTimeFormat resolves the time options of a time.Time field.
+// The first option which isn't a known option is a layout as well (the legacy layout), and the layouts are tried in order.
+// The options which aren't time options are returned
+func buildTimeFormat(fieldType reflect.StructField, options []tagOption, clock func() time.Time) (format *timeFormat, left []tagOption, errs []error) {
+	format = &timeFormat{}
+	addLayout := func(layout string) {
+		if named, ok := namedLayouts[layout]; ok {
+			layout = named
+		}
+		format.layouts = append(format.layouts, layout)
+	}
+
+	legacyLayout := false
+	for _, option := range options {
+		switch {
+		case option.key == layoutOption:
+			if option.value == "" {
+				errs = append(errs, &InvalidOptionError{option: option.key, fieldName: fieldType.Name, typ: fieldType.Type})
+				continue
+			}
+			addLayout(option.value)
+		case !legacyLayout && !option.hasValue && !knownOptions[option.key]:
+			legacyLayout = true
+			addLayout(option.key)
+		case option.key == locOption:
+			loc, err := time.LoadLocation(option.value)
+			if err != nil || option.value == "" {
+				errs = append(errs, &InvalidOptionValueError{option: option.key, value: option.value, fieldName: fieldType.Name, err: err})
+				continue
+			}
+			format.loc = loc
+		case option.key == inferYearOption:
+			format.clock = clock
+		case epochUnits[option.key] != 0:
+			if format.unit != 0 {
+				errs = append(errs, &InvalidOptionError{option: option.key, fieldName: fieldType.Name, typ: fieldType.Type})
+			}
+			format.unit = epochUnits[option.key]
+		default:
+			left = append(left, option)
+		}
+	}
+
+	if format.unit != 0 && len(format.layouts) > 0 {
+		errs = append(errs, &InvalidOptionError{option: layoutOption, fieldName: fieldType.Name, typ: fieldType.Type})
+	}
+	if format.unit != 0 && format.clock != nil {
+		errs = append(errs, &InvalidOptionError{option: inferYearOption, fieldName: fieldType.Name, typ: fieldType.Type})
+	}
+	if format.unit == 0 && len(format.layouts) == 0 {
+		format.layouts = []string{time.RFC3339}
+	}
+	return format, left, errs
+}
+
+// parseLayout parses s by the layout, in the location of the format if it's set
+func (t *timeFormat) parseLayout(layout, s string) (time.Time, error) {
+	if t.loc == nil {
+		return time.Parse(layout, s)
+	}
+	return time.ParseInLocation(layout, s, t.loc)
+}
+
+// parse parses the value by the first layout which matches it, or as a unix epoch
+func (t *timeFormat) parse(s string) (time.Time, error) {
+	if t.unit != 0 {
+		return t.parseEpoch(s)
+	}
+
+	var firstErr error
+	for _, layout := range t.layouts {
+		parsed, err := t.parseLayout(layout, s)
+		if err == nil {
+			return t.inferYear(parsed), nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if len(t.layouts) == 1 {
+		return time.Time{}, firstErr
+	}
+	return time.Time{}, fmt.Errorf("%q doesn't match any of the layouts %q", s, t.layouts)
+}
+
+// inferYear sets the year of a value without a year (parsed as year 0) to the year of the clock,
+// or to the year before if it would be more than a day ahead of the clock (such as December logs read in January).
+// Feb 29 is set to the most recent leap year which isn't ahead of the clock
+func (t *timeFormat) inferYear(parsed time.Time) time.Time {
+	if t.clock == nil || parsed.Year() != 0 {
+		return parsed
+	}
+	now := t.clock()
+	limit := now.Add(24 * time.Hour)
+	for year := now.Year(); ; year-- {
+		inferred := time.Date(year, parsed.Month(), parsed.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(), parsed.Nanosecond(), parsed.Location())
+		// The date doesn't exist in the year if it was normalised to another day
+		if inferred.Day() == parsed.Day() && !inferred.After(limit) {
+			return inferred
+		}
+	}
+}
+
+// parseEpoch parses a unix epoch in the format unit, seconds may have a fraction (such as `1700000000.5`)
+func (t *timeFormat) parseEpoch(s string) (time.Time, error) {
+	whole, fraction, hasFraction := strings.Cut(s, ".")
+	if hasFraction && t.unit != time.Second {
+		return time.Time{}, fmt.Errorf("invalid unix epoch %q", s)
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var nanos int64
+	if hasFraction {
+		if fraction == "" || len(fraction) > 9 || strings.TrimLeft(fraction, "0123456789") != "" {
+			return time.Time{}, fmt.Errorf("invalid unix epoch %q", s)
+		}
+		nanos, _ = strconv.ParseInt(fraction+strings.Repeat("0", 9-len(fraction)), 10, 64)
+		if strings.HasPrefix(whole, "-") {
+			nanos = -nanos
+		}
+	}
+
+	var parsed time.Time
+	switch t.unit {
+	case time.Second:
+		parsed = time.Unix(units, nanos)
+	case time.Millisecond:
+		parsed = time.Unix(units/1e3, units%1e3*1e6)
+	default:
+		parsed = time.Unix(0, units)
+	}
+	if t.loc == nil {
+		return parsed.UTC(), nil
+	}
+	return parsed.In(t.loc), nil
+}
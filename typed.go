@@ -0,0 +1,109 @@
+package regroup
+
+import (
+	"reflect"
+)
+
+// Typed is a ReGroup bound to a struct type T.
+// T can be either a struct or a pointer to a struct, matches are returned as T without any type assertions
+type Typed[T any] struct {
+	re   *ReGroup
+	plan *structPlan
+}
+
+// CompileFor compiles given expression as regex and return new Typed bound to T.
+// If the expression can't be compiled as regex, a CompileError will be returned.
+// If T is not a struct or a pointer to a struct, a NotStructError will be returned.
+// T is validated against the compiled regex (see Validate), so any problem in its struct tags is returned here
+func CompileFor[T any](expr string, opts ...Option) (*Typed[T], error) {
+	typ, err := typedStructType[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	re, err := Compile(expr, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, errs := re.plan(typ)
+	if len(errs) > 0 {
+		return nil, &ValidationError{errs: errs}
+	}
+
+	return &Typed[T]{re: re, plan: plan}, nil
+}
+
+// MustCompileFor calls CompileFor and panics if it returns an error
+func MustCompileFor[T any](expr string, opts ...Option) *Typed[T] {
+	typed, err := CompileFor[T](expr, opts...)
+	if err != nil {
+		panic(`regroup: CompileFor(` + quote(expr) + `): ` + err.Error())
+	}
+	return typed
+}
+
+// typedStructType returns the struct type T refers to, either directly or as a pointer
+func typedStructType[T any]() (reflect.Type, error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, &NotStructError{typ: reflect.TypeOf((*T)(nil)).Elem()}
+	}
+	return typ, nil
+}
+
+// ReGroup returns the underlying ReGroup matcher
+func (t *Typed[T]) ReGroup() *ReGroup {
+	return t.re
+}
+
+// newTarget returns a pointer to a new T and a reference to the struct it holds
+func (t *Typed[T]) newTarget() (*T, reflect.Value) {
+	ret := new(T)
+	targetRef := reflect.ValueOf(ret).Elem()
+	if targetRef.Kind() == reflect.Ptr {
+		targetRef.Set(reflect.New(targetRef.Type().Elem()))
+		targetRef = targetRef.Elem()
+	}
+	return ret, targetRef
+}
+
+// Match matches the regex expression to string s and parse it into a new T.
+// If no matches found, a &NoMatchFoundError error will be returned
+func (t *Typed[T]) Match(s string) (T, error) {
+	var ret T
+	match := t.plan.expr.matcher.FindStringSubmatchIndex(s)
+	if match == nil {
+		return ret, &NoMatchFoundError{}
+	}
+
+	target, targetRef := t.newTarget()
+	if err := t.plan.fill(s, match, targetRef); err != nil {
+		return ret, err
+	}
+	return *target, nil
+}
+
+// MatchAll will find all the regex matches for given string 's' (up to n matches, all matches if n < 0),
+// and parse each of them into a new T.
+// If no matches found, a &NoMatchFoundError error will be returned
+func (t *Typed[T]) MatchAll(s string, n int) ([]T, error) {
+	matches := t.plan.expr.matcher.FindAllStringSubmatchIndex(s, n)
+	if matches == nil {
+		return nil, &NoMatchFoundError{}
+	}
+
+	ret := make([]T, len(matches))
+	for i, match := range matches {
+		target, targetRef := t.newTarget()
+		if err := t.plan.fill(s, match, targetRef); err != nil {
+			return nil, err
+		}
+		ret[i] = *target
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,62 @@
+package regroup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileFor(t *testing.T) {
+	_, err := CompileFor[Including](`(?P<duration>.*?)\s+(?P<num>\d+)\s+(?P<str>.*)`)
+	require.NoError(t, err)
+
+	_, err = CompileFor[*Including](`(?P<duration>.*?)\s+(?P<num>\d+)\s+(?P<str>.*)`)
+	require.NoError(t, err)
+
+	_, err = CompileFor[int](`(?P<num>\d+)`)
+	isErrorMatch(t, &NotStructError{}, err)
+
+	_, err = CompileFor[Including]("invlid[")
+	isErrorMatch(t, &CompileError{}, err)
+
+	assert.Panics(t, func() { MustCompileFor[Including]("invlid[") })
+}
+
+func TestTypedMatch(t *testing.T) {
+	r := MustCompileFor[Including](`(?P<duration>.*?)\s+(?P<num>\d+)\s+(?P<str>.*)`)
+
+	got, err := r.Match("5s 123 foo")
+	require.NoError(t, err)
+	assert.Equal(t, Including{Single: Single{Duration: 5 * time.Second}, Num: 123, Str: "foo"}, got)
+
+	_, err = r.Match("5s aa foo")
+	isErrorMatch(t, &NoMatchFoundError{}, err)
+
+	_, err = r.Match("5ls 123 foo")
+	isErrorMatch(t, &ParseError{}, err)
+
+	ptr := MustCompileFor[*Including](`(?P<duration>.*?)\s+(?P<num>\d+)\s+(?P<str>.*)`)
+	gotPtr, err := ptr.Match("5s 123 foo")
+	require.NoError(t, err)
+	assert.Equal(t, &Including{Single: Single{Duration: 5 * time.Second}, Num: 123, Str: "foo"}, gotPtr)
+}
+
+func TestTypedMatchAll(t *testing.T) {
+	r := MustCompileFor[*Single](`(?P<duration>.*?)\s+(?P<num>\d+)\s+(?P<str>.*)`)
+
+	got, err := r.MatchAll("5s 123 foo\n8h 123 foo", -1)
+	require.NoError(t, err)
+	assert.Equal(t, []*Single{{Duration: 5 * time.Second}, {Duration: 8 * time.Hour}}, got)
+
+	got, err = r.MatchAll("5s 123 foo\n8h 123 foo", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []*Single{{Duration: 5 * time.Second}}, got)
+
+	_, err = r.MatchAll("5s aa foo", -1)
+	isErrorMatch(t, &NoMatchFoundError{}, err)
+
+	_, err = r.MatchAll("5s 123 foo\n8ls 123 foo", -1)
+	isErrorMatch(t, &ParseError{}, err)
+}
@@ -0,0 +1,108 @@
+package regroup
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	bytesOption   = "bytes"
+	siOption      = "si"
+	iecOption     = "iec"
+	percentOption = "percent"
+)
+
+// decimalPrefixes are the exponents of the unit prefixes, of 1000 or of 1024 if the units are binary
+var decimalPrefixes = map[string]int64{"": 0, "k": 1, "K": 1, "M": 2, "G": 3, "T": 4, "P": 5, "E": 6}
+
+// binaryPrefixes are the exponents of the IEC unit prefixes, which are always of 1024
+var binaryPrefixes = map[string]int64{"Ki": 1, "Mi": 2, "Gi": 3, "Ti": 4, "Pi": 5, "Ei": 6}
+
+// splitNumber splits a decimal number (such as `-12.5`) from the unit following it
+func splitNumber(src string) (number, unit string) {
+	i := 0
+	if i < len(src) && (src[i] == '-' || src[i] == '+') {
+		i++
+	}
+	for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+		i++
+	}
+	return src[:i], strings.TrimSpace(src[i:])
+}
+
+// parseUnits parses a number with a unit prefix into base units, such as `12.5M` with si or `3GiB` and `450 kB/s` with bytes
+func (n *numberFormat) parseUnits(src string) (*big.Rat, error) {
+	number, unit := splitNumber(strings.TrimSpace(src))
+	value, ok := new(big.Rat).SetString(number)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", src)
+	}
+
+	// Rates are parsed as the number of units per second
+	prefix := strings.TrimSuffix(unit, "/s")
+	if n.bytes {
+		prefix = strings.TrimSuffix(prefix, "B")
+	}
+
+	base, exp := int64(1000), int64(0)
+	if n.binary {
+		base = 1024
+	}
+	if binaryExp, ok := binaryPrefixes[prefix]; ok {
+		base, exp = 1024, binaryExp
+	} else if exp, ok = decimalPrefixes[prefix]; !ok {
+		return nil, fmt.Errorf("unknown unit %q", unit)
+	}
+
+	multiplier := new(big.Int).Exp(big.NewInt(base), big.NewInt(exp), nil)
+	return value.Mul(value, new(big.Rat).SetInt(multiplier)), nil
+}
+
+// parsePercent parses a percentage (such as `87.3%`) into a fraction, the `%` sign is optional
+func parsePercent(src string) (*big.Rat, error) {
+	number, unit := splitNumber(strings.TrimSpace(src))
+	value, ok := new(big.Rat).SetString(number)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", src)
+	}
+	if unit != "" && unit != "%" {
+		return nil, fmt.Errorf("unknown unit %q", unit)
+	}
+	return value.Quo(value, big.NewRat(100, 1)), nil
+}
+
+// parseScaled parses a number with units or a percentage into typ, integers are truncated
+func (n *numberFormat) parseScaled(src string, typ reflect.Type) (reflect.Value, error) {
+	src = n.clean(src)
+	var value *big.Rat
+	var err error
+	if n.percent {
+		value, err = parsePercent(src)
+	} else {
+		value, err = n.parseUnits(src)
+	}
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	// The scaled value is parsed as a plain number, to check it's in the range of typ
+	plain := &numberFormat{overflow: n.overflow, base: 10}
+	switch typ.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f, _ := value.Float64()
+		if math.IsInf(f, 0) {
+			if err := n.rangeError(strconv.ErrRange, src, typ); err != nil {
+				return reflect.Value{}, err
+			}
+			f = math.Copysign(math.MaxFloat64, f)
+		}
+		return plain.parseFloat(strconv.FormatFloat(f, 'g', -1, 64), typ)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return plain.parseUInt(new(big.Int).Quo(value.Num(), value.Denom()).String(), typ)
+	}
+	return plain.parseInt(new(big.Int).Quo(value.Num(), value.Denom()).String(), typ)
+}
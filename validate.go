@@ -0,0 +1,35 @@
+package regroup
+
+import (
+	"reflect"
+)
+
+// Validate walks the struct type once and checks that every tagged group exists in the compiled regex,
+// every field type is parsable and every option is known.
+// typ can be either a struct or a struct pointer type, otherwise a NotStructError will be returned.
+// All the problems found are returned together as a ValidationError.
+// A valid type is compiled into a plan which is cached and reused by all the matching methods
+func (r *ReGroup) Validate(typ reflect.Type) error {
+	structType := typ
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return &NotStructError{typ: typ}
+	}
+
+	if _, errs := r.plan(structType); len(errs) > 0 {
+		return &ValidationError{errs: errs}
+	}
+	return nil
+}
+
+// Bind validates the type of given target, which must be a struct pointer, against the compiled regex.
+// It's meant to be called once before processing any input, see Validate
+func (r *ReGroup) Bind(target interface{}) error {
+	targetRef, err := r.validateTarget(target)
+	if err != nil {
+		return err
+	}
+	return r.Validate(targetRef.Type())
+}
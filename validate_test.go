@@ -0,0 +1,127 @@
+package regroup
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	r := MustCompile(`(?P<duration>.*?)\s+(?P<num>\d+)\s+(?P<str>.*)`)
+
+	type unparsable struct {
+		Ch chan int `regroup:"str"`
+	}
+
+	type manyProblems struct {
+		Str    string    `regroup:"strr"`
+		Num    int       `regroup:"num,requird"`
+		Exists int       `regroup:"num,exists"`
+		Ch     chan int  `regroup:"str"`
+		Time   time.Time `regroup:"time"`
+		Nested *IncorrectGroup
+	}
+
+	type unexported struct {
+		str string `regroup:"not_found"`
+	}
+
+	tests := []struct {
+		name     string
+		typ      reflect.Type
+		wantErr  error
+		wantErrs []error
+	}{
+		{
+			name: "Valid struct",
+			typ:  reflect.TypeOf(Including{}),
+		},
+		{
+			name: "Valid struct pointer",
+			typ:  reflect.TypeOf(&IncludingPointers{}),
+		},
+		{
+			name: "Unexported fields are ignored",
+			typ:  reflect.TypeOf(unexported{}),
+		},
+		{
+			name:    "Not a struct",
+			typ:     reflect.TypeOf(5),
+			wantErr: &NotStructError{},
+		},
+		{
+			name:     "Unknown group",
+			typ:      reflect.TypeOf(IncorrectGroup{}),
+			wantErr:  &ValidationError{},
+			wantErrs: []error{&UnknownGroupError{group: "not_found"}},
+		},
+		{
+			name:     "Not parsable",
+			typ:      reflect.TypeOf(unparsable{}),
+			wantErr:  &ValidationError{},
+			wantErrs: []error{&TypeNotParsableError{reflect.TypeOf(make(chan int))}},
+		},
+		{
+			name:    "All problems together",
+			typ:     reflect.TypeOf(manyProblems{}),
+			wantErr: &ValidationError{},
+			wantErrs: []error{
+				&UnknownGroupError{group: "strr"},
+				&UnknownOptionError{option: "requird", fieldName: "Num"},
+				&InvalidOptionError{option: "exists", fieldName: "Exists", typ: reflect.TypeOf(0)},
+				&TypeNotParsableError{reflect.TypeOf(make(chan int))},
+				&UnknownGroupError{group: "time"},
+				&UnknownGroupError{group: "not_found"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.Validate(tt.typ)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			isErrorMatch(t, tt.wantErr, err)
+			if tt.wantErrs != nil {
+				assert.Equal(t, tt.wantErrs, err.(*ValidationError).Errors())
+			}
+		})
+	}
+}
+
+func TestBind(t *testing.T) {
+	r := MustCompile(`(?P<duration>.*?)\s+(?P<num>\d+)\s+(?P<str>.*)`)
+
+	require.NoError(t, r.Bind(&Including{}))
+	isErrorMatch(t, &NotStructPtrError{}, r.Bind(Including{}))
+	isErrorMatch(t, &ValidationError{}, r.Bind(&IncorrectGroup{}))
+
+	_, err := CompileFor[IncorrectGroup](`(?P<duration>.*?)\s+(?P<num>\d+)\s+(?P<str>.*)`)
+	isErrorMatch(t, &ValidationError{}, err)
+}
+
+func TestMatchBeforeValidation(t *testing.T) {
+	r := MustCompile(`(?P<duration>.*?)\s+(?P<num>\d+)\s+(?P<str>.*)`)
+
+	// Strings which don't match are reported before the problems of the target
+	isErrorMatch(t, &NoMatchFoundError{}, r.MatchToTarget("no match", &IncorrectGroup{}))
+	_, err := r.MatchAllToTarget("no match", -1, &IncorrectGroup{})
+	isErrorMatch(t, &NoMatchFoundError{}, err)
+	isErrorMatch(t, &UnknownGroupError{}, r.MatchToTarget("5s 1 foo", &IncorrectGroup{}))
+
+	// Unknown options are ignored when matching, and reported by Validate
+	type unknownOption struct {
+		Str  string   `regroup:"str,foo"`
+		Strs []string `regroup:"str,bar"`
+	}
+	target := &unknownOption{}
+	require.NoError(t, r.MatchToTarget("5s 1 foo", target))
+	assert.Equal(t, &unknownOption{Str: "foo", Strs: []string{"foo"}}, target)
+	isErrorMatch(t, &ValidationError{}, r.Bind(&unknownOption{}))
+	// The plan is cached, and still reports them
+	isErrorMatch(t, &ValidationError{}, r.Bind(&unknownOption{}))
+}